@@ -1,42 +1,86 @@
 package barber
 
 import (
-	"math"
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Barber is fast and easy to use circuit-breaker implementation
+//
+// Each host has its own closed/open/half-open state machine. Host is opened when
+// it has more than MaxFails fails (or more than MaxFailRate percent of fails
+// for TripByRate strategy) in Threshold buckets of BucketWidth. After OpenTimeout the host
+// becomes half-opened and HalfOpenRequests trial requests are allowed to pass.
+// An error reported for the half-opened host opens it again, HalfOpenRequests
+// successes close it.
 type Barber interface {
 	IsAvailable(serverID int, tm time.Time) bool
+	Allow(serverID int, tm time.Time) bool
 	AddError(serverID int, tm time.Time)
+	AddSuccess(serverID int, tm time.Time)
+	AddHost(serverID int)
+	RemoveHost(serverID int)
+	Reset(serverID int)
+	Execute(ctx context.Context, serverID int, fn, fallback Func) error
 	Stats() *Stats
 }
 
-// fail describes a single registered fail.
+// bucket describes fails and successes registered in a single time slot of BucketWidth.
 //
 // No reason is recorded for performance reasons.
-type fail struct {
-	lastTS int64
-	count  uint32
+type bucket struct {
+	lastTS    int64
+	fails     uint32
+	successes uint32
 }
 
 // host describes a single given host for circuit breaker.
 //
 // A single host is described in NewBarber initialization.
 type host struct {
-	mu    sync.RWMutex
-	fails []*fail
+	mu          sync.RWMutex
+	buckets     []*bucket
+	bucketWidth int64
+
+	// state is read atomically on the fast path,
+	// all the transitions are made under stateMu.
+	state     uint32
+	stateMu   sync.Mutex
+	changedAt int64
+	trials    uint32
+	probes    uint32
+	trips     uint64
+
+	onStateChange func(from, to State)
+}
+
+func newHost(cfg *Config, onStateChange func(from, to State)) *host {
+	h := &host{
+		buckets:       make([]*bucket, cfg.Threshold),
+		bucketWidth:   int64(cfg.BucketWidth),
+		onStateChange: onStateChange,
+	}
+	for i := 0; i < len(h.buckets); i++ {
+		h.buckets[i] = &bucket{}
+	}
+	return h
+}
+
+// slot returns the time slot of the given timestamp.
+func (h *host) slot(tm time.Time) int64 {
+	return tm.UnixNano() / h.bucketWidth
 }
 
 func (h *host) countFails(ts int64, maxAllowed uint32) (res uint32) {
-	timeThreshold := int64(len(h.fails))
+	timeThreshold := int64(len(h.buckets))
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	for i := 0; i < len(h.fails); i++ {
-		if ts-h.fails[i].lastTS < timeThreshold {
-			res += h.fails[i].count
+	for i := 0; i < len(h.buckets); i++ {
+		if ts-h.buckets[i].lastTS < timeThreshold {
+			res += h.buckets[i].fails
 		}
 		if res > maxAllowed {
 			break
@@ -46,76 +90,342 @@ func (h *host) countFails(ts int64, maxAllowed uint32) (res uint32) {
 	return
 }
 
-// addFails counts a single fail in according timestamp.
+// hasFails reports whether the host has any fails in the window.
+func (h *host) hasFails(ts int64) bool {
+	timeThreshold := int64(len(h.buckets))
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for i := 0; i < len(h.buckets); i++ {
+		if ts-h.buckets[i].lastTS < timeThreshold && h.buckets[i].fails > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// countRequests returns the amount of fails and successes in the window.
+func (h *host) countRequests(ts int64) (fails, successes uint32) {
+	timeThreshold := int64(len(h.buckets))
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for i := 0; i < len(h.buckets); i++ {
+		if ts-h.buckets[i].lastTS < timeThreshold {
+			fails += h.buckets[i].fails
+			successes += h.buckets[i].successes
+		}
+	}
+
+	return
+}
+
+// tripped checks whether the host must be opened according to the trip strategy.
+func (h *host) tripped(ts int64, cfg *Config) bool {
+	if cfg.TripStrategy != TripByRate {
+		return h.countFails(ts, cfg.MaxFails) > cfg.MaxFails
+	}
+
+	fails, successes := h.countRequests(ts)
+	total := fails + successes
+	if total == 0 || total < cfg.MinRequests {
+		return false
+	}
+
+	return float64(fails)*100 > cfg.MaxFailRate*float64(total)
+}
+
+// bucket returns the bucket for the given time slot. It MUST be called under mu.
+func (h *host) bucket(ts int64) *bucket {
+	b := h.buckets[ts%int64(len(h.buckets))]
+	if b.lastTS != ts {
+		b.lastTS = ts
+		b.fails = 0
+		b.successes = 0
+	}
+	return b
+}
+
+// addFail counts a single fail in according time slot.
 func (h *host) addFail(ts int64) {
-	idx := ts % int64(len(h.fails))
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	h1 := h.fails[idx]
-	if h1.lastTS != ts {
-		h1.lastTS = ts
-		h1.count = 1
-	} else {
-		h1.count++
+	h.bucket(ts).fails++
+}
+
+// countSuccess counts a single success in according time slot.
+func (h *host) countSuccess(ts int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.bucket(ts).successes++
+}
+
+// isAvailable returns the availability status of host without changing its state.
+func (h *host) isAvailable(tm time.Time, cfg *Config) bool {
+	if h.loadState() == StateClosed {
+		return !h.tripped(h.slot(tm), cfg)
+	}
+
+	return h.probe(tm, cfg)
+}
+
+// reserve returns the availability status of host and takes
+// a trial request slot if the host is half-opened.
+func (h *host) reserve(tm time.Time, cfg *Config) bool {
+	if h.loadState() == StateClosed && !h.tripped(h.slot(tm), cfg) {
+		return true
+	}
+
+	return h.allow(tm, cfg)
+}
+
+// addError registers a fail and updates the host state.
+func (h *host) addError(tm time.Time) {
+	h.addFail(h.slot(tm))
+	h.onError(tm)
+}
+
+// addSuccess registers a success and updates the host state.
+//
+// Successes are not needed to trip the host by count, so they are not registered
+// for the closed host without fails and the write lock is not taken on the hot path.
+func (h *host) addSuccess(tm time.Time, cfg *Config) {
+	ts := h.slot(tm)
+	if cfg.TripStrategy == TripByCount && h.loadState() == StateClosed && !h.hasFails(ts) {
+		return
+	}
+
+	h.countSuccess(ts)
+	h.onSuccess(tm, cfg)
+}
+
+// stats returns the host counters for the given timestamp.
+func (h *host) stats(tm time.Time) (fails, successes uint32, state State, trips uint64) {
+	fails, successes = h.countRequests(h.slot(tm))
+	return fails, successes, h.loadState(), atomic.LoadUint64(&h.trips)
+}
+
+// resetFails forgets all the registered fails and successes.
+func (h *host) resetFails() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, v := range h.buckets {
+		v.lastTS = 0
+		v.fails = 0
+		v.successes = 0
+	}
+}
+
+// StateChangeFunc is called when the host changes its state.
+//
+// It is called synchronously, so it should not block.
+type StateChangeFunc func(serverID int, from, to State)
+
+// Option is an optional Barber parameter.
+type Option func(*barber)
+
+// WithOnStateChange sets the callback called on each host state change.
+func WithOnStateChange(fn StateChangeFunc) Option {
+	return func(b *barber) {
+		b.onStateChange = fn
 	}
 }
 
 type barber struct {
-	config *Config
-	hosts  map[int]*host
+	config        *Config
+	onStateChange StateChangeFunc
+	isFailure     FailurePredicate
+
+	mu    sync.RWMutex
+	hosts map[int]*host
+
+	// removed keeps the state of the removed hosts for IdleTimeout,
+	// so the host removed and added back does not reset its breaker.
+	removed map[int]*removedHost
+}
+
+// removedHost is a tombstone of the removed host.
+type removedHost struct {
+	*host
+
+	removedAt int64
 }
 
 // NewBarber creates new cirulnik-barber for a given hosts list.
-func NewBarber(hosts []int, config *Config) Barber {
-	config = config.withDefaults()
+func NewBarber(hosts []int, config *Config, opts ...Option) Barber {
+	b := &barber{
+		config:    config.withDefaults(),
+		isFailure: DefaultFailurePredicate,
+		hosts:     make(map[int]*host, len(hosts)),
+		removed:   make(map[int]*removedHost),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
 
-	mp := make(map[int]*host)
 	for _, v := range hosts {
-		mp[v] = &host{
-			fails: make([]*fail, config.Threshold),
-		}
-		for j := 0; j < len(mp[v].fails); j++ {
-			mp[v].fails[j] = &fail{}
+		b.hosts[v] = b.newHost(v)
+	}
+
+	return b
+}
+
+func (b *barber) newHost(serverID int) *host {
+	if b.onStateChange == nil {
+		return newHost(b.config, nil)
+	}
+
+	return newHost(b.config, func(from, to State) {
+		b.onStateChange(serverID, from, to)
+	})
+}
+
+func (b *barber) getHost(serverID int) (*host, bool) {
+	b.mu.RLock()
+	h, ok := b.hosts[serverID]
+	b.mu.RUnlock()
+
+	return h, ok
+}
+
+// AddHost registers the host with given ID.
+//
+// Registration of the already known host keeps its state and fails.
+// The host removed less than IdleTimeout ago restores its state and fails.
+func (b *barber) AddHost(serverID int) {
+	now := time.Now().UnixNano()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.evictRemoved(now)
+	if _, ok := b.hosts[serverID]; ok {
+		return
+	}
+
+	if r, ok := b.removed[serverID]; ok {
+		delete(b.removed, serverID)
+		b.hosts[serverID] = r.host
+		return
+	}
+
+	b.hosts[serverID] = b.newHost(serverID)
+}
+
+// RemoveHost unregisters the host with given ID.
+//
+// Removed host is not available. Its state is kept for IdleTimeout
+// to be restored if the host is added again.
+func (b *barber) RemoveHost(serverID int) {
+	now := time.Now().UnixNano()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.evictRemoved(now)
+	h, ok := b.hosts[serverID]
+	if !ok {
+		return
+	}
+
+	delete(b.hosts, serverID)
+	b.removed[serverID] = &removedHost{
+		host:      h,
+		removedAt: now,
+	}
+}
+
+// evictRemoved forgets the state of the hosts removed at least IdleTimeout ago.
+//
+// It MUST be called under mu.
+func (b *barber) evictRemoved(now int64) {
+	idle := int64(b.config.IdleTimeout)
+	for k, v := range b.removed {
+		if now-v.removedAt >= idle {
+			delete(b.removed, k)
 		}
 	}
+}
 
-	return &barber{
-		config: config,
-		hosts:  mp,
+// Reset closes the host with given ID and forgets all its fails.
+func (b *barber) Reset(serverID int) {
+	h, ok := b.getHost(serverID)
+	if !ok {
+		return
 	}
+
+	h.reset(time.Now())
 }
 
 // IsAvailable returns the availability status of host
+//
+// It does not change the host state, so it suits health checks.
+// Use Allow before sending the request to the host.
 func (b *barber) IsAvailable(serverID int, tm time.Time) bool {
-	h, ok := b.hosts[serverID]
+	h, ok := b.getHost(serverID)
 	if !ok {
 		return false
 	}
 
-	res := h.countFails(tm.Unix(), b.config.MaxFails)
-	return res <= b.config.MaxFails
+	return h.isAvailable(tm, b.config)
+}
+
+// Allow reports whether the request can be sent to the host.
+//
+// Unlike IsAvailable, it opens the host which has exceeded the fails limit
+// and takes one of HalfOpenRequests trial request slots of the half-opened host,
+// so the result of the allowed request should be reported.
+func (b *barber) Allow(serverID int, tm time.Time) bool {
+	h, ok := b.getHost(serverID)
+	if !ok {
+		return false
+	}
+
+	return h.reserve(tm, b.config)
 }
 
 // AddError adds error to the selected host with given timestamp
 func (b *barber) AddError(serverID int, tm time.Time) {
-	h, ok := b.hosts[serverID]
+	h, ok := b.getHost(serverID)
 	if !ok {
 		return
 	}
 
-	h.addFail(tm.Unix())
+	h.addError(tm)
+}
+
+// AddSuccess adds success to the selected host with given timestamp.
+//
+// Successes are used to calculate fail rate and to close the half-opened host.
+func (b *barber) AddSuccess(serverID int, tm time.Time) {
+	h, ok := b.getHost(serverID)
+	if !ok {
+		return
+	}
+
+	h.addSuccess(tm, b.config)
 }
 
 // Stats returns error statistics for all hosts
 func (b *barber) Stats() *Stats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
 	stats := &Stats{}
 	stats.Hosts = make([]*StatHost, 0, len(b.hosts))
-	now := time.Now().Unix()
+	now := time.Now()
 	for k, v := range b.hosts {
 		s := &StatHost{}
 		s.ServerID = k
-		s.FailsCount = int(v.countFails(now, math.MaxUint32))
+		fails, successes, state, trips := v.stats(now)
+		s.FailsCount = int(fails)
+		s.SuccessCount = int(successes)
+		s.State = state
+		s.TripsCount = int(trips)
 
 		stats.Hosts = append(stats.Hosts, s)
 	}
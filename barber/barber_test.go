@@ -1,9 +1,14 @@
 package barber
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"github.com/city-mobil/gobuns/config"
 )
 
@@ -58,6 +63,421 @@ func TestHostFails(t *testing.T) {
 	}
 }
 
+func TestHalfOpen(t *testing.T) {
+	cfg := &Config{
+		Threshold:        10,
+		MaxFails:         2,
+		OpenTimeout:      time.Second,
+		HalfOpenRequests: 2,
+	}
+	barb := NewBarber([]int{0}, cfg)
+
+	tm := time.Now()
+	for i := 0; i < 3; i++ {
+		barb.AddError(0, tm)
+	}
+	if barb.Allow(0, tm) {
+		t.Fatal("expected host to be opened")
+	}
+	if barb.Allow(0, tm.Add(500*time.Millisecond)) {
+		t.Fatal("expected host to be opened before open timeout")
+	}
+
+	tm = tm.Add(time.Second)
+	if !barb.Allow(0, tm) || !barb.Allow(0, tm) {
+		t.Fatal("expected trial requests to pass")
+	}
+	if barb.Allow(0, tm) {
+		t.Fatal("expected only 2 trial requests to pass")
+	}
+	if st := barb.Stats().Hosts[0].State; st != StateHalfOpen {
+		t.Fatalf("want state %v, got %v", StateHalfOpen, st)
+	}
+
+	barb.AddError(0, tm)
+	if barb.Allow(0, tm) {
+		t.Fatal("expected host to be opened after failed trial")
+	}
+
+	tm = tm.Add(time.Second)
+	if !barb.Allow(0, tm) || !barb.Allow(0, tm) {
+		t.Fatal("expected trial requests to pass")
+	}
+	barb.AddSuccess(0, tm)
+	barb.AddSuccess(0, tm)
+	if st := barb.Stats().Hosts[0].State; st != StateClosed {
+		t.Fatalf("want state %v, got %v", StateClosed, st)
+	}
+	if !barb.Allow(0, tm) {
+		t.Fatal("expected host to be closed after successful trials")
+	}
+}
+
+func TestHalfOpenWithoutSuccessReports(t *testing.T) {
+	barb := NewBarber([]int{0}, &Config{
+		Threshold:   10,
+		MaxFails:    1,
+		OpenTimeout: time.Second,
+	})
+
+	tm := time.Now()
+	barb.AddError(0, tm)
+	barb.AddError(0, tm)
+	if barb.Allow(0, tm) {
+		t.Fatal("expected host to be opened")
+	}
+
+	tm = tm.Add(time.Second)
+	if !barb.Allow(0, tm) {
+		t.Fatal("expected trial request to pass")
+	}
+	if barb.Allow(0, tm) {
+		t.Fatal("expected only 1 trial request to pass")
+	}
+
+	tm = tm.Add(time.Second)
+	if !barb.Allow(0, tm) {
+		t.Fatal("expected host to be closed when no errors were reported for trials")
+	}
+}
+
+func TestIsAvailableDoesNotTakeTrials(t *testing.T) {
+	barb := NewBarber([]int{0}, &Config{
+		Threshold:   10,
+		MaxFails:    1,
+		OpenTimeout: time.Second,
+	})
+
+	tm := time.Now()
+	barb.AddError(0, tm)
+	barb.AddError(0, tm)
+	if barb.IsAvailable(0, tm) {
+		t.Fatal("expected host to be unavailable")
+	}
+	if st := barb.Stats().Hosts[0].State; st != StateClosed {
+		t.Fatalf("want state %v, got %v", StateClosed, st)
+	}
+	if barb.Allow(0, tm) {
+		t.Fatal("expected host to be opened")
+	}
+
+	tm = tm.Add(time.Second)
+	for i := 0; i < 3; i++ {
+		if !barb.IsAvailable(0, tm) {
+			t.Fatal("expected host to be available after open timeout")
+		}
+	}
+	if !barb.Allow(0, tm) {
+		t.Fatal("expected trial request to pass")
+	}
+	if st := barb.Stats().Hosts[0].State; st != StateHalfOpen {
+		t.Fatalf("want state %v, got %v", StateHalfOpen, st)
+	}
+
+	for i := 0; i < 3; i++ {
+		if barb.IsAvailable(0, tm) {
+			t.Fatal("expected host to be unavailable while trial request is in progress")
+		}
+	}
+	barb.AddSuccess(0, tm)
+	if !barb.IsAvailable(0, tm) {
+		t.Fatal("expected host to be closed after successful trial")
+	}
+}
+
+func TestTripByRate(t *testing.T) {
+	var testData = []struct {
+		fails             int
+		successes         int
+		expectedAvailable bool
+		testName          string
+	}{
+		{
+			fails:             60,
+			successes:         1000,
+			expectedAvailable: true,
+			testName:          "low_fail_rate",
+		},
+		{
+			fails:             40,
+			successes:         0,
+			expectedAvailable: false,
+			testName:          "high_fail_rate",
+		},
+		{
+			fails:             5,
+			successes:         0,
+			expectedAvailable: true,
+			testName:          "not_enough_requests",
+		},
+	}
+
+	for _, v := range testData {
+		v := v
+		t.Run(v.testName, func(t *testing.T) {
+			barb := NewBarber([]int{0}, &Config{
+				Threshold:    10,
+				MaxFails:     20,
+				TripStrategy: TripByRate,
+				MaxFailRate:  50,
+				MinRequests:  10,
+			})
+
+			tm := time.Now()
+			for i := 0; i < v.fails; i++ {
+				barb.AddError(0, tm)
+			}
+			for i := 0; i < v.successes; i++ {
+				barb.AddSuccess(0, tm)
+			}
+
+			isAvailable := barb.IsAvailable(0, tm)
+			if v.expectedAvailable != isAvailable {
+				t.Errorf("want %v, got %v", v.expectedAvailable, isAvailable)
+			}
+		})
+	}
+}
+
+func TestDynamicHosts(t *testing.T) {
+	barb := NewBarber(nil, &Config{
+		Threshold: 10,
+		MaxFails:  2,
+	})
+
+	tm := time.Now()
+	if barb.IsAvailable(1, tm) {
+		t.Fatal("expected unknown host to be unavailable")
+	}
+
+	barb.AddHost(1)
+	if !barb.IsAvailable(1, tm) {
+		t.Fatal("expected added host to be available")
+	}
+
+	for i := 0; i < 3; i++ {
+		barb.AddError(1, tm)
+	}
+	barb.AddHost(1)
+	if barb.IsAvailable(1, tm) {
+		t.Fatal("expected re-registered host to keep its fails")
+	}
+
+	barb.Reset(1)
+	if !barb.IsAvailable(1, tm) {
+		t.Fatal("expected host to be available after reset")
+	}
+
+	barb.RemoveHost(1)
+	if barb.IsAvailable(1, tm) {
+		t.Fatal("expected removed host to be unavailable")
+	}
+	if len(barb.Stats().Hosts) != 0 {
+		t.Fatal("expected no hosts in stats")
+	}
+}
+
+func TestRemovedHostKeepsState(t *testing.T) {
+	barb := NewBarber([]int{1, 2}, &Config{
+		Threshold:   10,
+		MaxFails:    1,
+		OpenTimeout: time.Minute,
+		IdleTimeout: 50 * time.Millisecond,
+	})
+
+	tm := time.Now()
+	for _, id := range []int{1, 2} {
+		barb.AddError(id, tm)
+		barb.AddError(id, tm)
+		if barb.IsAvailable(id, tm) {
+			t.Fatalf("expected host %d to be opened", id)
+		}
+	}
+
+	barb.RemoveHost(1)
+	barb.AddHost(1)
+	if barb.IsAvailable(1, tm) {
+		t.Fatal("expected re-added host to stay opened")
+	}
+	if st := barb.Stats().Hosts; len(st) != 2 {
+		t.Fatalf("want 2 hosts in stats, got %d", len(st))
+	}
+
+	barb.RemoveHost(2)
+	time.Sleep(60 * time.Millisecond)
+	barb.AddHost(2)
+	if !barb.IsAvailable(2, tm) {
+		t.Fatal("expected host re-added after IdleTimeout to be closed")
+	}
+}
+
+func TestOnStateChange(t *testing.T) {
+	type change struct {
+		serverID int
+		from, to State
+	}
+	var changes []change
+	barb := NewBarber([]int{1}, &Config{
+		Threshold:   10,
+		MaxFails:    1,
+		OpenTimeout: time.Second,
+	}, WithOnStateChange(func(serverID int, from, to State) {
+		changes = append(changes, change{serverID, from, to})
+	}))
+
+	tm := time.Now()
+	barb.AddError(1, tm)
+	barb.AddError(1, tm)
+	barb.Allow(1, tm)
+	barb.Allow(1, tm.Add(time.Second))
+	barb.AddSuccess(1, tm.Add(time.Second))
+
+	expected := []change{
+		{1, StateClosed, StateOpen},
+		{1, StateOpen, StateHalfOpen},
+		{1, StateHalfOpen, StateClosed},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("want %d state changes, got %d", len(expected), len(changes))
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("want change %v, got %v", expected[i], changes[i])
+		}
+	}
+
+	if trips := barb.Stats().Hosts[0].TripsCount; trips != 1 {
+		t.Errorf("want trips count 1, got %d", trips)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	barb := NewBarber([]int{1}, &Config{
+		Threshold: 10,
+		MaxFails:  1,
+	})
+	barb.AddError(1, time.Now())
+	barb.AddError(1, time.Now())
+	barb.Allow(1, time.Now())
+
+	expected := `
+		# HELP circuit_breaker_state Circuit breaker host state: 0 - closed, 1 - open, 2 - half-open
+		# TYPE circuit_breaker_state gauge
+		circuit_breaker_state{breaker="test",host="1"} 1
+		# HELP circuit_breaker_trips_total Circuit breaker host trips count
+		# TYPE circuit_breaker_trips_total counter
+		circuit_breaker_trips_total{breaker="test",host="1"} 1
+	`
+	err := testutil.CollectAndCompare(
+		NewCollector("test", barb), strings.NewReader(expected),
+		"circuit_breaker_state", "circuit_breaker_trips_total",
+	)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubSecondBuckets(t *testing.T) {
+	barb := NewBarber([]int{0}, &Config{
+		Threshold:   20,
+		BucketWidth: 10 * time.Millisecond,
+		MaxFails:    5,
+		OpenTimeout: 100 * time.Millisecond,
+	})
+
+	tm := time.Now()
+	for i := 0; i < 4; i++ {
+		barb.AddError(0, tm)
+	}
+	for i := 0; i < 4; i++ {
+		barb.AddError(0, tm.Add(250*time.Millisecond))
+	}
+	if !barb.Allow(0, tm.Add(250*time.Millisecond)) {
+		t.Fatal("expected fails older than the window to be forgotten")
+	}
+
+	for i := 0; i < 2; i++ {
+		barb.AddError(0, tm.Add(300*time.Millisecond))
+	}
+	if barb.Allow(0, tm.Add(300*time.Millisecond)) {
+		t.Fatal("expected host to be opened")
+	}
+	if !barb.Allow(0, tm.Add(400*time.Millisecond)) {
+		t.Fatal("expected host to be half-opened after open timeout")
+	}
+}
+
+func TestExecute(t *testing.T) {
+	errIgnored := errors.New("ignored")
+	barb := NewBarber([]int{0}, &Config{
+		Threshold: 10,
+		MaxFails:  1,
+	}, WithFailurePredicate(func(err error) bool {
+		return err != errIgnored
+	}))
+	ctx := context.Background()
+
+	errFail := errors.New("fail")
+	failing := func(context.Context) error { return errFail }
+	ignored := func(context.Context) error { return errIgnored }
+	fallback := func(context.Context) error { return nil }
+
+	for i := 0; i < 5; i++ {
+		if err := barb.Execute(ctx, 0, ignored, nil); err != errIgnored {
+			t.Fatalf("want error %v, got %v", errIgnored, err)
+		}
+	}
+	if !barb.IsAvailable(0, time.Now()) {
+		t.Fatal("expected ignored errors not to be counted")
+	}
+
+	_ = barb.Execute(ctx, 0, failing, nil)
+	_ = barb.Execute(ctx, 0, failing, nil)
+
+	if err := barb.Execute(ctx, 0, failing, nil); err != ErrCircuitOpen {
+		t.Errorf("want error %v, got %v", ErrCircuitOpen, err)
+	}
+	if err := barb.Execute(ctx, 0, failing, fallback); err != nil {
+		t.Errorf("expected fallback to be called, got %v", err)
+	}
+	if err := barb.Execute(ctx, 42, failing, nil); err != ErrCircuitOpen {
+		t.Errorf("want error %v for unknown host, got %v", ErrCircuitOpen, err)
+	}
+}
+
+func TestExecuteCanceledProbe(t *testing.T) {
+	barb := NewBarber([]int{0}, &Config{
+		Threshold:        10,
+		MaxFails:         1,
+		OpenTimeout:      50 * time.Millisecond,
+		HalfOpenRequests: 2,
+	})
+	ctx := context.Background()
+
+	tm := time.Now()
+	barb.AddError(0, tm)
+	barb.AddError(0, tm)
+	if barb.Allow(0, tm) {
+		t.Fatal("expected host to be opened")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	canceled := func(context.Context) error { return context.Canceled }
+	succeeded := func(context.Context) error { return nil }
+
+	if err := barb.Execute(ctx, 0, canceled, nil); err != context.Canceled {
+		t.Fatalf("want error %v, got %v", context.Canceled, err)
+	}
+	if err := barb.Execute(ctx, 0, succeeded, nil); err != nil {
+		t.Fatalf("expected trial request to pass, got %v", err)
+	}
+	// NOTE: the canceled probe is neither a success nor a failure.
+	if st := barb.Stats().Hosts[0].State; st != StateHalfOpen {
+		t.Fatalf("want state %v, got %v", StateHalfOpen, st)
+	}
+}
+
 func TestNewConfig(t *testing.T) {
 	cfg := NewConfig("")
 	_ = config.InitOnce()
@@ -114,6 +534,7 @@ func TestStats(t *testing.T) {
 	barber.AddError(1, time.Now())
 	barber.AddError(1, time.Now())
 	barber.AddError(1, time.Now())
+	barber.AddSuccess(1, time.Now())
 
 	st := barber.Stats()
 	if len(st.Hosts) != 1 {
@@ -127,6 +548,40 @@ func TestStats(t *testing.T) {
 	if h.FailsCount != 3 {
 		t.Errorf("got fails count %d, expected 2", h.FailsCount)
 	}
+	if h.SuccessCount != 1 {
+		t.Errorf("got success count %d, expected 1", h.SuccessCount)
+	}
+}
+
+func TestAddSuccessWithoutFails(t *testing.T) {
+	barb := NewBarber([]int{1, 2}, &Config{
+		Threshold: 10,
+	})
+	rateBarb := NewBarber([]int{1}, &Config{
+		Threshold:    10,
+		TripStrategy: TripByRate,
+	})
+
+	tm := time.Now()
+	barb.AddSuccess(1, tm)
+	barb.AddError(2, tm)
+	barb.AddSuccess(2, tm)
+	rateBarb.AddSuccess(1, tm)
+
+	// NOTE: successes of the host without fails are not needed by count strategy.
+	st := barb.Stats()
+	for _, h := range st.Hosts {
+		want := 0
+		if h.ServerID == 2 {
+			want = 1
+		}
+		if h.SuccessCount != want {
+			t.Errorf("host %d: got success count %d, expected %d", h.ServerID, h.SuccessCount, want)
+		}
+	}
+	if cnt := rateBarb.Stats().Hosts[0].SuccessCount; cnt != 1 {
+		t.Errorf("got success count %d for rate strategy, expected 1", cnt)
+	}
 }
 
 func BenchmarkBarberAddError(b *testing.B) {
@@ -154,6 +609,20 @@ func BenchmarkBarberAddError(b *testing.B) {
 	})
 }
 
+func BenchmarkBarberAddSuccess(b *testing.B) {
+	barber := NewBarber([]int{1}, &Config{
+		Threshold: 42,
+	})
+	tm := time.Now()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			barber.AddSuccess(1, tm)
+		}
+	})
+}
+
 func BenchmarkBarberIsAvailable(b *testing.B) {
 	// NOTE(a.petrukhin): circuit_breaker package has 4000 ns/op and 700B/op per insert
 	// This barber is faster on inserts, but 100ns slowlier for select because of the
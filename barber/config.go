@@ -1,12 +1,35 @@
 package barber
 
 import (
+	"time"
+
 	"github.com/city-mobil/gobuns/config"
 )
 
+// TripStrategy describes the condition to open the host.
+type TripStrategy string
+
+const (
+	// TripByCount opens the host when it has more than MaxFails fails.
+	TripByCount TripStrategy = "count"
+
+	// TripByRate opens the host when the percent of fails is more than MaxFailRate
+	// and the host has at least MinRequests requests.
+	TripByRate TripStrategy = "rate"
+)
+
 const (
-	defaultThreshold = 42
-	defaultMaxFails  = 50
+	defaultThreshold   = 42
+	defaultMaxFails    = 50
+	defaultBucketWidth = time.Second
+
+	defaultHalfOpenRequests = 1
+
+	defaultTripStrategy = TripByCount
+	defaultMaxFailRate  = 50
+	defaultMinRequests  = 20
+
+	defaultIdleTimeout = 10 * time.Minute
 )
 
 type Config struct {
@@ -15,26 +38,82 @@ type Config struct {
 	//
 	// That means that if you have an error on the first second, then
 	// this error is forgotten on first + threshold second.
+	//
+	// Threshold is measured in buckets of BucketWidth, which is one second by default.
 	Threshold uint32
 
+	// BucketWidth is a width of a single bucket of the fails window.
+	//
+	// The window of Threshold buckets with BucketWidth of 10ms
+	// allows the breaker to react in Threshold * 10ms.
+	BucketWidth time.Duration
+
 	// MaxFails indicates an allowed amount of fails to believe that host is alive.
 	//
 	// Hosts with total amount of errors more than MaxFails in a time of Threshold
 	// will be marked as unavailable.
 	MaxFails uint32
+
+	// TripStrategy is a condition to open the host.
+	//
+	// By default: TripByCount.
+	TripStrategy TripStrategy
+
+	// MaxFailRate is an allowed percent of fails for TripByRate strategy.
+	MaxFailRate float64
+
+	// MinRequests is a minimal amount of requests in a time of Threshold
+	// to apply TripByRate strategy.
+	MinRequests uint32
+
+	// IdleTimeout is a period of time after which unused key
+	// is evicted from KeyedBarber and the state of the host
+	// removed from Barber is forgotten.
+	IdleTimeout time.Duration
+
+	// OpenTimeout is a period of time after which the opened host
+	// becomes half-opened.
+	//
+	// Threshold * BucketWidth is used by default.
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is an amount of trial requests allowed for the half-opened host.
+	//
+	// The same amount of successful requests is required to close the host.
+	HalfOpenRequests uint32
 }
 
-func NewConfig(prefix string) func() *Config {
+func NewConfig(prefix string, set ...*config.FlagSet) func() *Config {
+	fs := config.FlagSetOrDefault(set...)
+
 	// TODO(a.petrukhin): implement
 	prefix += "."
 	var (
-		threshold = config.Uint32(prefix+"threshold", defaultThreshold, "Circuit breaker closing threshold")
-		maxFails  = config.Uint32(prefix+"max_fails", defaultMaxFails, "Circuit breaker max fails amount")
+		threshold = fs.Uint32(prefix+"threshold", defaultThreshold, "Circuit breaker closing threshold in buckets")
+		maxFails  = fs.Uint32(prefix+"max_fails", defaultMaxFails, "Circuit breaker max fails amount")
+
+		bucketWidth = fs.Duration(prefix+"bucket_width", defaultBucketWidth, "Circuit breaker fails window bucket width")
+
+		openTimeout      = fs.Duration(prefix+"open_timeout", 0, "Circuit breaker open state duration, threshold is used if not set")
+		halfOpenRequests = fs.Uint32(prefix+"half_open_requests", defaultHalfOpenRequests, "Circuit breaker trial requests amount in half-open state")
+
+		tripStrategy = fs.String(prefix+"trip_strategy", string(defaultTripStrategy), "Circuit breaker trip strategy: rate, count (default)")
+		maxFailRate  = fs.Float64(prefix+"max_fail_rate", defaultMaxFailRate, "Circuit breaker max fails percent for rate strategy")
+		minRequests  = fs.Uint32(prefix+"min_requests", defaultMinRequests, "Circuit breaker min requests amount for rate strategy")
+
+		idleTimeout = fs.Duration(prefix+"idle_timeout", defaultIdleTimeout, "Keyed circuit breaker unused key and removed host eviction timeout")
 	)
 	return func() *Config {
 		return &Config{
-			Threshold: *threshold,
-			MaxFails:  *maxFails,
+			Threshold:        *threshold,
+			BucketWidth:      *bucketWidth,
+			MaxFails:         *maxFails,
+			OpenTimeout:      *openTimeout,
+			HalfOpenRequests: *halfOpenRequests,
+			TripStrategy:     TripStrategy(*tripStrategy),
+			MaxFailRate:      *maxFailRate,
+			MinRequests:      *minRequests,
+			IdleTimeout:      *idleTimeout,
 		}
 	}
 }
@@ -54,5 +133,33 @@ func (c *Config) withDefaults() *Config {
 	if c.MaxFails == 0 {
 		c.MaxFails = defaultMaxFails
 	}
+
+	if c.BucketWidth <= 0 {
+		c.BucketWidth = defaultBucketWidth
+	}
+
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = time.Duration(c.Threshold) * c.BucketWidth
+	}
+
+	if c.HalfOpenRequests == 0 {
+		c.HalfOpenRequests = defaultHalfOpenRequests
+	}
+
+	if c.TripStrategy != TripByRate {
+		c.TripStrategy = defaultTripStrategy
+	}
+
+	if c.MaxFailRate <= 0 || c.MaxFailRate > 100 {
+		c.MaxFailRate = defaultMaxFailRate
+	}
+
+	if c.MinRequests == 0 {
+		c.MinRequests = defaultMinRequests
+	}
+
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = defaultIdleTimeout
+	}
 	return c
 }
@@ -0,0 +1,91 @@
+package barber
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Execute when the host is not available
+// and no fallback is given.
+var ErrCircuitOpen = errors.New("barber: circuit is open")
+
+// FailurePredicate reports whether the error returned by the protected call
+// is a host failure.
+//
+// Errors which are not failures (e.g. redis.Nil) are counted as successes.
+// Canceled calls are not reported regardless of the predicate.
+type FailurePredicate func(err error) bool
+
+// DefaultFailurePredicate treats all errors except context cancellation as failures.
+func DefaultFailurePredicate(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// Func is a call protected by the circuit-breaker.
+type Func func(ctx context.Context) error
+
+// WithFailurePredicate sets the predicate used by Execute to classify errors.
+func WithFailurePredicate(fn FailurePredicate) Option {
+	return func(b *barber) {
+		if fn != nil {
+			b.isFailure = fn
+		}
+	}
+}
+
+// WithKeyFailurePredicate sets the predicate used by KeyedBarber.Execute to classify errors.
+func WithKeyFailurePredicate(fn FailurePredicate) KeyedOption {
+	return func(b *keyedBarber) {
+		if fn != nil {
+			b.isFailure = fn
+		}
+	}
+}
+
+// execute runs fn if the host is available and reports its result.
+//
+// Context cancellation proves nothing about the host, so it is not reported.
+func execute(ctx context.Context, h *host, cfg *Config, isFailure FailurePredicate, fn, fallback Func) error {
+	if !h.reserve(time.Now(), cfg) {
+		if fallback != nil {
+			return fallback(ctx)
+		}
+		return ErrCircuitOpen
+	}
+
+	err := fn(ctx)
+	switch {
+	case errors.Is(err, context.Canceled):
+	case err != nil && isFailure(err):
+		h.addError(time.Now())
+	default:
+		h.addSuccess(time.Now(), cfg)
+	}
+
+	return err
+}
+
+// Execute runs fn if the host is available and reports its result to the circuit-breaker.
+//
+// If the host is not available, fallback is called instead. If fallback is nil,
+// ErrCircuitOpen is returned.
+func (b *barber) Execute(ctx context.Context, serverID int, fn, fallback Func) error {
+	h, ok := b.getHost(serverID)
+	if !ok {
+		if fallback != nil {
+			return fallback(ctx)
+		}
+		return ErrCircuitOpen
+	}
+
+	return execute(ctx, h, b.config, b.isFailure, fn, fallback)
+}
+
+// Execute runs fn if the key is available and reports its result to the circuit-breaker.
+//
+// If the key is not available, fallback is called instead. If fallback is nil,
+// ErrCircuitOpen is returned.
+func (b *keyedBarber) Execute(ctx context.Context, key string, fn, fallback Func) error {
+	return execute(ctx, b.getHost(key, time.Now()), b.config, b.isFailure, fn, fallback)
+}
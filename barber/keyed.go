@@ -0,0 +1,181 @@
+package barber
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// KeyedBarber is a circuit-breaker for resources identified by arbitrary string keys,
+// e.g. upstream hostnames or endpoints.
+//
+// Host state is created lazily on the first use of the key and is evicted
+// after Config.IdleTimeout of inactivity. The state machine and the fails window
+// are the same as in Barber.
+type KeyedBarber interface {
+	IsAvailable(key string, tm time.Time) bool
+	Allow(key string, tm time.Time) bool
+	AddError(key string, tm time.Time)
+	AddSuccess(key string, tm time.Time)
+	Reset(key string)
+	Execute(ctx context.Context, key string, fn, fallback Func) error
+	Stats() *KeyedStats
+}
+
+// KeyedStateChangeFunc is called when the keyed host changes its state.
+//
+// It is called synchronously, so it should not block.
+type KeyedStateChangeFunc func(key string, from, to State)
+
+// KeyedOption is an optional KeyedBarber parameter.
+type KeyedOption func(*keyedBarber)
+
+// WithOnKeyStateChange sets the callback called on each keyed host state change.
+func WithOnKeyStateChange(fn KeyedStateChangeFunc) KeyedOption {
+	return func(b *keyedBarber) {
+		b.onStateChange = fn
+	}
+}
+
+type keyedHost struct {
+	*host
+
+	lastUsed int64
+}
+
+type keyedBarber struct {
+	config        *Config
+	onStateChange KeyedStateChangeFunc
+	isFailure     FailurePredicate
+
+	mu           sync.RWMutex
+	hosts        map[string]*keyedHost
+	lastEviction int64
+}
+
+// NewKeyedBarber creates new cirulnik-barber for string keys.
+func NewKeyedBarber(config *Config, opts ...KeyedOption) KeyedBarber {
+	b := &keyedBarber{
+		config:       config.withDefaults(),
+		isFailure:    DefaultFailurePredicate,
+		hosts:        make(map[string]*keyedHost),
+		lastEviction: time.Now().UnixNano(),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+func (b *keyedBarber) newHost(key string) *keyedHost {
+	if b.onStateChange == nil {
+		return &keyedHost{host: newHost(b.config, nil)}
+	}
+
+	return &keyedHost{host: newHost(b.config, func(from, to State) {
+		b.onStateChange(key, from, to)
+	})}
+}
+
+// getHost returns the host for the given key creating it if necessary.
+func (b *keyedBarber) getHost(key string, tm time.Time) *host {
+	b.mu.RLock()
+	h, ok := b.hosts[key]
+	b.mu.RUnlock()
+
+	if !ok {
+		b.evictIdle(tm)
+
+		b.mu.Lock()
+		h, ok = b.hosts[key]
+		if !ok {
+			h = b.newHost(key)
+			b.hosts[key] = h
+		}
+		b.mu.Unlock()
+	}
+
+	atomic.StoreInt64(&h.lastUsed, tm.UnixNano())
+	return h.host
+}
+
+// evictIdle removes the hosts which have not been used for IdleTimeout.
+//
+// Idle hosts are checked only when a new key appears and no more often than once per IdleTimeout.
+func (b *keyedBarber) evictIdle(tm time.Time) {
+	now := tm.UnixNano()
+	idle := int64(b.config.IdleTimeout)
+	last := atomic.LoadInt64(&b.lastEviction)
+	if now-last < idle || !atomic.CompareAndSwapInt64(&b.lastEviction, last, now) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for k, v := range b.hosts {
+		if now-atomic.LoadInt64(&v.lastUsed) >= idle {
+			delete(b.hosts, k)
+		}
+	}
+}
+
+// IsAvailable returns the availability status of the key.
+//
+// Unknown key is believed to be available.
+// It does not change the key state, use Allow before sending the request.
+func (b *keyedBarber) IsAvailable(key string, tm time.Time) bool {
+	return b.getHost(key, tm).isAvailable(tm, b.config)
+}
+
+// Allow reports whether the request for the key can be sent and takes
+// a trial request slot if the key is half-opened.
+func (b *keyedBarber) Allow(key string, tm time.Time) bool {
+	return b.getHost(key, tm).reserve(tm, b.config)
+}
+
+// AddError adds error to the selected key with given timestamp.
+func (b *keyedBarber) AddError(key string, tm time.Time) {
+	b.getHost(key, tm).addError(tm)
+}
+
+// AddSuccess adds success to the selected key with given timestamp.
+func (b *keyedBarber) AddSuccess(key string, tm time.Time) {
+	b.getHost(key, tm).addSuccess(tm, b.config)
+}
+
+// Reset closes the host with given key and forgets all its fails.
+func (b *keyedBarber) Reset(key string) {
+	b.mu.RLock()
+	h, ok := b.hosts[key]
+	b.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	h.reset(time.Now())
+}
+
+// Stats returns error statistics for all known keys.
+func (b *keyedBarber) Stats() *KeyedStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := &KeyedStats{}
+	stats.Hosts = make([]*KeyedStatHost, 0, len(b.hosts))
+	now := time.Now()
+	for k, v := range b.hosts {
+		s := &KeyedStatHost{}
+		s.Key = k
+		fails, successes, state, trips := v.stats(now)
+		s.FailsCount = int(fails)
+		s.SuccessCount = int(successes)
+		s.State = state
+		s.TripsCount = int(trips)
+
+		stats.Hosts = append(stats.Hosts, s)
+	}
+	return stats
+}
@@ -0,0 +1,75 @@
+package barber
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyedBarber(t *testing.T) {
+	var changes []string
+	barb := NewKeyedBarber(&Config{
+		Threshold:   10,
+		MaxFails:    1,
+		OpenTimeout: time.Second,
+	}, WithOnKeyStateChange(func(key string, from, to State) {
+		changes = append(changes, key+":"+from.String()+"->"+to.String())
+	}))
+
+	tm := time.Now()
+	if !barb.Allow("api.example.com", tm) {
+		t.Fatal("expected unknown key to be available")
+	}
+
+	barb.AddError("api.example.com", tm)
+	barb.AddError("api.example.com", tm)
+	if barb.Allow("api.example.com", tm) {
+		t.Fatal("expected key to be opened")
+	}
+	if !barb.Allow("other.example.com", tm) {
+		t.Fatal("expected other key to be available")
+	}
+
+	if len(changes) != 1 || changes[0] != "api.example.com:closed->open" {
+		t.Errorf("unexpected state changes %v", changes)
+	}
+
+	barb.Reset("api.example.com")
+	if !barb.Allow("api.example.com", tm) {
+		t.Fatal("expected key to be available after reset")
+	}
+
+	if len(barb.Stats().Hosts) != 2 {
+		t.Errorf("got hosts len %d, expected 2", len(barb.Stats().Hosts))
+	}
+}
+
+func TestKeyedBarberEviction(t *testing.T) {
+	barb := NewKeyedBarber(&Config{
+		Threshold:   10,
+		MaxFails:    1,
+		IdleTimeout: time.Minute,
+	})
+
+	tm := time.Now()
+	barb.AddError("a", tm)
+	barb.AddError("a", tm)
+	if barb.IsAvailable("a", tm) {
+		t.Fatal("expected key to be opened")
+	}
+
+	barb.AddError("b", tm.Add(30*time.Second))
+	if len(barb.Stats().Hosts) != 2 {
+		t.Fatalf("got hosts len %d, expected 2", len(barb.Stats().Hosts))
+	}
+
+	barb.IsAvailable("c", tm.Add(time.Minute+time.Second))
+	st := barb.Stats()
+	if len(st.Hosts) != 2 {
+		t.Fatalf("got hosts len %d, expected 2", len(st.Hosts))
+	}
+	for _, v := range st.Hosts {
+		if v.Key == "a" {
+			t.Error("expected idle key to be evicted")
+		}
+	}
+}
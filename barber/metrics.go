@@ -0,0 +1,135 @@
+package barber
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/city-mobil/gobuns/promlib"
+)
+
+const (
+	metricsSubsystem = "circuit_breaker"
+
+	labelBreaker = "breaker"
+	labelHost    = "host"
+)
+
+// hostMetrics describes a single host values exported by collector.
+type hostMetrics struct {
+	id        string
+	fails     int
+	successes int
+	state     State
+	trips     int
+}
+
+// collector exports Barber stats as prometheus metrics on each scrape.
+type collector struct {
+	hosts func() []hostMetrics
+
+	fails     *prometheus.Desc
+	successes *prometheus.Desc
+	state     *prometheus.Desc
+	trips     *prometheus.Desc
+}
+
+// NewCollector creates prometheus collector for the given Barber.
+//
+// The name is used as the "breaker" label value, so it must be unique
+// for all the collectors registered in one registry. Server IDs are exported
+// in the "host" label.
+func NewCollector(name string, b Barber) prometheus.Collector {
+	return newCollector(name, func() []hostMetrics {
+		st := b.Stats()
+		res := make([]hostMetrics, 0, len(st.Hosts))
+		for _, v := range st.Hosts {
+			res = append(res, hostMetrics{
+				id:        strconv.Itoa(v.ServerID),
+				fails:     v.FailsCount,
+				successes: v.SuccessCount,
+				state:     v.State,
+				trips:     v.TripsCount,
+			})
+		}
+		return res
+	})
+}
+
+// NewKeyedCollector creates prometheus collector for the given KeyedBarber.
+//
+// Keys are exported in the "host" label, so the amount of keys should be limited.
+func NewKeyedCollector(name string, b KeyedBarber) prometheus.Collector {
+	return newCollector(name, func() []hostMetrics {
+		st := b.Stats()
+		res := make([]hostMetrics, 0, len(st.Hosts))
+		for _, v := range st.Hosts {
+			res = append(res, hostMetrics{
+				id:        v.Key,
+				fails:     v.FailsCount,
+				successes: v.SuccessCount,
+				state:     v.State,
+				trips:     v.TripsCount,
+			})
+		}
+		return res
+	})
+}
+
+func newCollector(name string, hosts func() []hostMetrics) *collector {
+	ns := promlib.GetGlobalNamespace()
+	labels := []string{labelHost}
+	constLabels := prometheus.Labels{labelBreaker: name}
+
+	return &collector{
+		hosts: hosts,
+		fails: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, metricsSubsystem, "fails"),
+			"Circuit breaker host fails count in the threshold window",
+			labels, constLabels,
+		),
+		successes: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, metricsSubsystem, "successes"),
+			"Circuit breaker host successes count in the threshold window",
+			labels, constLabels,
+		),
+		state: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, metricsSubsystem, "state"),
+			"Circuit breaker host state: 0 - closed, 1 - open, 2 - half-open",
+			labels, constLabels,
+		),
+		trips: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, metricsSubsystem, "trips_total"),
+			"Circuit breaker host trips count",
+			labels, constLabels,
+		),
+	}
+}
+
+// RegisterMetrics registers prometheus collector for the given Barber
+// in the default registry.
+func RegisterMetrics(name string, b Barber) error {
+	return prometheus.Register(NewCollector(name, b))
+}
+
+// RegisterKeyedMetrics registers prometheus collector for the given KeyedBarber
+// in the default registry.
+func RegisterKeyedMetrics(name string, b KeyedBarber) error {
+	return prometheus.Register(NewKeyedCollector(name, b))
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.fails
+	ch <- c.successes
+	ch <- c.state
+	ch <- c.trips
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for _, v := range c.hosts() {
+		ch <- prometheus.MustNewConstMetric(c.fails, prometheus.GaugeValue, float64(v.fails), v.id)
+		ch <- prometheus.MustNewConstMetric(c.successes, prometheus.GaugeValue, float64(v.successes), v.id)
+		ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, float64(v.state), v.id)
+		ch <- prometheus.MustNewConstMetric(c.trips, prometheus.CounterValue, float64(v.trips), v.id)
+	}
+}
@@ -0,0 +1,164 @@
+package barber
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// State describes the state of the circuit breaker for a single host.
+type State uint32
+
+const (
+	// StateClosed means that the host is available and all the requests pass.
+	StateClosed State = iota
+	// StateOpen means that the host is not available and all the requests are rejected.
+	StateOpen
+	// StateHalfOpen means that the host is probed with a limited amount of trial requests.
+	StateHalfOpen
+)
+
+// String returns the human readable name of the state.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+func (h *host) loadState() State {
+	return State(atomic.LoadUint32(&h.state))
+}
+
+// lockState locks the host state and returns its current value.
+func (h *host) lockState() State {
+	h.stateMu.Lock()
+	return h.loadState()
+}
+
+// unlockState unlocks the host state and reports the state change
+// if the state differs from the given one.
+func (h *host) unlockState(from State) {
+	to := h.loadState()
+	h.stateMu.Unlock()
+
+	if from != to && h.onStateChange != nil {
+		h.onStateChange(from, to)
+	}
+}
+
+// setState MUST be called under stateMu.
+func (h *host) setState(s State, tm time.Time) {
+	if s == StateOpen {
+		atomic.AddUint64(&h.trips, 1)
+	}
+	atomic.StoreUint32(&h.state, uint32(s))
+	h.changedAt = tm.UnixNano()
+	h.trials = 0
+	h.probes = 0
+}
+
+// allow decides whether the request can pass if the host is not in the closed
+// state or has just exceeded the fails limit.
+func (h *host) allow(tm time.Time, cfg *Config) bool {
+	defer h.unlockState(h.lockState())
+
+	switch h.loadState() {
+	case StateClosed:
+		// NOTE: the state could have been changed by another goroutine,
+		// so fails are recounted under the lock.
+		if !h.tripped(h.slot(tm), cfg) {
+			return true
+		}
+		h.setState(StateOpen, tm)
+		return false
+	case StateOpen:
+		if tm.UnixNano()-h.changedAt < int64(cfg.OpenTimeout) {
+			return false
+		}
+		h.setState(StateHalfOpen, tm)
+	}
+
+	if h.trials < cfg.HalfOpenRequests {
+		h.trials++
+		return true
+	}
+
+	// NOTE: callers which report only errors never send the verdict
+	// for the trial requests. If no error has been reported during OpenTimeout,
+	// the trials are believed to be successful.
+	if tm.UnixNano()-h.changedAt >= int64(cfg.OpenTimeout) {
+		h.close(tm)
+		return true
+	}
+
+	return false
+}
+
+// probe decides whether the request can pass if the host is not in the closed state
+// the same way as allow does, but neither changes the state nor takes a trial request slot.
+func (h *host) probe(tm time.Time, cfg *Config) bool {
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+
+	elapsed := tm.UnixNano() - h.changedAt
+	switch h.loadState() {
+	case StateClosed:
+		return !h.tripped(h.slot(tm), cfg)
+	case StateOpen:
+		return elapsed >= int64(cfg.OpenTimeout)
+	default:
+		return h.trials < cfg.HalfOpenRequests || elapsed >= int64(cfg.OpenTimeout)
+	}
+}
+
+// onError re-opens the half-opened host.
+func (h *host) onError(tm time.Time) {
+	if h.loadState() != StateHalfOpen {
+		return
+	}
+
+	defer h.unlockState(h.lockState())
+
+	if h.loadState() == StateHalfOpen {
+		h.setState(StateOpen, tm)
+	}
+}
+
+// onSuccess closes the half-opened host when enough trial requests succeeded.
+func (h *host) onSuccess(tm time.Time, cfg *Config) {
+	if h.loadState() != StateHalfOpen {
+		return
+	}
+
+	defer h.unlockState(h.lockState())
+
+	if h.loadState() != StateHalfOpen {
+		return
+	}
+
+	h.probes++
+	if h.probes >= cfg.HalfOpenRequests {
+		h.close(tm)
+	}
+}
+
+// reset closes the host regardless of its current state.
+func (h *host) reset(tm time.Time) {
+	defer h.unlockState(h.lockState())
+
+	h.close(tm)
+}
+
+// close MUST be called under stateMu.
+func (h *host) close(tm time.Time) {
+	// NOTE: fails which have tripped the breaker can still be in the window,
+	// they must be forgotten, otherwise the host is opened again on the next check.
+	h.resetFails()
+	h.setState(StateClosed, tm)
+}
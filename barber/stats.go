@@ -1,10 +1,25 @@
 package barber
 
 type StatHost struct {
-	ServerID   int
-	FailsCount int
+	ServerID     int
+	FailsCount   int
+	SuccessCount int
+	State        State
+	TripsCount   int
 }
 
 type Stats struct {
 	Hosts []*StatHost
 }
+
+type KeyedStatHost struct {
+	Key          string
+	FailsCount   int
+	SuccessCount int
+	State        State
+	TripsCount   int
+}
+
+type KeyedStats struct {
+	Hosts []*KeyedStatHost
+}
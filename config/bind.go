@@ -0,0 +1,439 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	tagName     = "config"
+	tagDefault  = "default"
+	tagUsage    = "usage"
+	tagValidate = "validate"
+)
+
+const (
+	ruleRequired = "required"
+	ruleMin      = "min"
+	ruleMax      = "max"
+	ruleOneOf    = "oneof"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// FieldError describes a configuration param which failed validation.
+type FieldError struct {
+	// Name is a full name of the param.
+	Name string
+	// Rule is a failed validation rule.
+	Rule string
+	// Msg describes the failure.
+	Msg string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Msg)
+}
+
+// ValidationError aggregates errors of all the invalid params.
+type ValidationError []*FieldError
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
+	}
+
+	return "invalid configuration: " + strings.Join(msgs, "; ")
+}
+
+func (e ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, fe := range e {
+		errs = append(errs, fe)
+	}
+	return errs
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+type fieldValidator struct {
+	name  string
+	value reflect.Value
+	rules []rule
+}
+
+// Bind registers the params of the global configuration from the fields
+// of the struct v points to. See FlagSet.Bind for details.
+func Bind(prefix string, v interface{}) error {
+	return defaultFlagSet.Bind(prefix, v)
+}
+
+// Bind registers the params from the fields of the struct v points to.
+// The fields are filled during Init and on each reload.
+//
+// The fields are described by tags:
+//   - `config:"name"` is a name of the param relative to the prefix, "-" skips the field,
+//   - `default:"value"` is a default value, the current field value is used if the tag is not set,
+//   - `usage:"text"` is a description of the param,
+//   - `validate:"rules"` is a comma separated list of the rules checked by Init:
+//     required, min=N, max=N and oneof=a b c.
+//
+// Nested structs are bound with the name of the field as a prefix, embedded structs
+// without the tag are bound with the same prefix. Fields without the tag are skipped.
+//
+// min and max limit values of numbers and durations or lengths of strings and slices.
+// oneof limits values of strings, numbers and elements of slices.
+func (f *FlagSet) Bind(prefix string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: Bind expects a pointer to struct, got %T", v)
+	}
+
+	if prefix != "" {
+		prefix = SanitizePrefix(prefix)
+	}
+
+	return f.bindStruct(prefix, rv.Elem())
+}
+
+func (f *FlagSet) bindStruct(prefix string, sv reflect.Value) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		// NOTE: exported fields of embedded unexported structs are settable.
+		if field.PkgPath != "" && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		key, tagged := field.Tag.Lookup(tagName)
+		if key == "-" {
+			continue
+		}
+
+		fv := sv.Field(i)
+		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+			if fv.IsNil() {
+				fv.Set(reflect.New(field.Type.Elem()))
+			}
+			fv = fv.Elem()
+		}
+
+		if fv.Kind() == reflect.Struct {
+			switch {
+			case tagged:
+				if err := f.bindStruct(prefix+key+".", fv); err != nil {
+					return err
+				}
+			case field.Anonymous:
+				if err := f.bindStruct(prefix, fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if !tagged {
+			continue
+		}
+
+		if err := f.bindField(prefix+key, field, fv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *FlagSet) bindField(name string, field reflect.StructField, fv reflect.Value) error {
+	register, err := registerFunc(fv)
+	if err != nil {
+		return fmt.Errorf("config: field %s: %w", field.Name, err)
+	}
+
+	if def, ok := field.Tag.Lookup(tagDefault); ok {
+		// NOTE: the default is parsed by a temporary flag set to get the same format as from the file.
+		tmp := pflag.NewFlagSet(name, pflag.ContinueOnError)
+		register(tmp, name, "")
+		if err = tmp.Set(name, def); err != nil {
+			return fmt.Errorf("config: field %s: invalid default: %w", field.Name, err)
+		}
+	}
+
+	rules, err := parseRules(field.Tag.Get(tagValidate), fv.Type())
+	if err != nil {
+		return fmt.Errorf("config: field %s: %w", field.Name, err)
+	}
+
+	register(f.FlagSet, name, field.Tag.Get(tagUsage))
+	if len(rules) > 0 {
+		f.validatorsMu.Lock()
+		f.validators = append(f.validators, &fieldValidator{
+			name:  name,
+			value: fv,
+			rules: rules,
+		})
+		f.validatorsMu.Unlock()
+	}
+
+	return nil
+}
+
+// registerFunc returns the function registering the flag bound to the field.
+func registerFunc(fv reflect.Value) (func(fs *pflag.FlagSet, name, usage string), error) {
+	switch p := fv.Addr().Interface().(type) {
+	case *string:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.StringVar(p, name, *p, usage) }, nil
+	case *bool:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.BoolVar(p, name, *p, usage) }, nil
+	case *int:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.IntVar(p, name, *p, usage) }, nil
+	case *int8:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Int8Var(p, name, *p, usage) }, nil
+	case *int16:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Int16Var(p, name, *p, usage) }, nil
+	case *int32:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Int32Var(p, name, *p, usage) }, nil
+	case *int64:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Int64Var(p, name, *p, usage) }, nil
+	case *uint:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.UintVar(p, name, *p, usage) }, nil
+	case *uint8:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Uint8Var(p, name, *p, usage) }, nil
+	case *uint16:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Uint16Var(p, name, *p, usage) }, nil
+	case *uint32:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Uint32Var(p, name, *p, usage) }, nil
+	case *uint64:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Uint64Var(p, name, *p, usage) }, nil
+	case *float32:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Float32Var(p, name, *p, usage) }, nil
+	case *float64:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Float64Var(p, name, *p, usage) }, nil
+	case *time.Duration:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.DurationVar(p, name, *p, usage) }, nil
+	case *[]string:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.StringSliceVar(p, name, *p, usage) }, nil
+	case *[]bool:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.BoolSliceVar(p, name, *p, usage) }, nil
+	case *[]int:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.IntSliceVar(p, name, *p, usage) }, nil
+	case *[]int32:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Int32SliceVar(p, name, *p, usage) }, nil
+	case *[]int64:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Int64SliceVar(p, name, *p, usage) }, nil
+	case *[]uint:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.UintSliceVar(p, name, *p, usage) }, nil
+	case *[]float32:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Float32SliceVar(p, name, *p, usage) }, nil
+	case *[]float64:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.Float64SliceVar(p, name, *p, usage) }, nil
+	case *[]time.Duration:
+		return func(fs *pflag.FlagSet, name, usage string) { fs.DurationSliceVar(p, name, *p, usage) }, nil
+	}
+
+	// NOTE: named types, e.g. `type Mode string`, are set by their kind.
+	switch fv.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return func(fs *pflag.FlagSet, name, usage string) {
+			fs.Var(&kindValue{v: fv}, name, usage)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", fv.Type())
+	}
+}
+
+// kindValue is a flag value of a named scalar type.
+type kindValue struct {
+	v reflect.Value
+}
+
+func (k *kindValue) String() string {
+	return fmt.Sprint(k.v.Interface())
+}
+
+func (k *kindValue) Set(s string) error {
+	switch k.v.Kind() {
+	case reflect.String:
+		k.v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		k.v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, k.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		k.v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, k.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		k.v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, k.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		k.v.SetFloat(n)
+	}
+
+	return nil
+}
+
+func (k *kindValue) Type() string {
+	return k.v.Kind().String()
+}
+
+func parseRules(tag string, typ reflect.Type) ([]rule, error) {
+	if tag == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(tag, ",")
+	rules := make([]rule, 0, len(parts))
+	for _, part := range parts {
+		name, param, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case ruleRequired:
+		case ruleMin, ruleMax:
+			if _, err := parseBound(param, typ); err != nil {
+				return nil, fmt.Errorf("invalid %s rule: %w", name, err)
+			}
+		case ruleOneOf:
+			if param == "" {
+				return nil, fmt.Errorf("empty %s rule", name)
+			}
+		default:
+			return nil, fmt.Errorf("unknown validation rule %q", name)
+		}
+
+		rules = append(rules, rule{name: name, param: param})
+	}
+
+	return rules, nil
+}
+
+// parseBound parses the param of min and max rules.
+func parseBound(param string, typ reflect.Type) (float64, error) {
+	if typ == durationType {
+		d, err := time.ParseDuration(param)
+		return float64(d), err
+	}
+
+	return strconv.ParseFloat(param, 64)
+}
+
+// measure returns the value compared with min and max rules.
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func (fv *fieldValidator) validate() []*FieldError {
+	var errs []*FieldError
+	fail := func(r rule, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{
+			Name: fv.name,
+			Rule: r.name,
+			Msg:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, r := range fv.rules {
+		switch r.name {
+		case ruleRequired:
+			if fv.value.IsZero() || (fv.value.Kind() == reflect.Slice && fv.value.Len() == 0) {
+				fail(r, "value is required")
+			}
+		case ruleMin, ruleMax:
+			bound, _ := parseBound(r.param, fv.value.Type())
+			val, ok := measure(fv.value)
+			if !ok {
+				continue
+			}
+			if r.name == ruleMin && val < bound {
+				fail(r, "value %v is less than %s", fv.value.Interface(), r.param)
+			}
+			if r.name == ruleMax && val > bound {
+				fail(r, "value %v is greater than %s", fv.value.Interface(), r.param)
+			}
+		case ruleOneOf:
+			allowed := strings.Fields(r.param)
+			values := []reflect.Value{fv.value}
+			if fv.value.Kind() == reflect.Slice {
+				values = values[:0]
+				for i := 0; i < fv.value.Len(); i++ {
+					values = append(values, fv.value.Index(i))
+				}
+			}
+			for _, v := range values {
+				if !oneOf(fmt.Sprint(v.Interface()), allowed) {
+					fail(r, "value %v is not one of %s", v.Interface(), strings.Join(allowed, ", "))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+func oneOf(v string, allowed []string) bool {
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks all the bound params and returns ValidationError if some of them are invalid.
+func (f *FlagSet) validate() error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.validateLocked()
+}
+
+// validateLocked MUST be called under mu.
+func (f *FlagSet) validateLocked() error {
+	f.validatorsMu.Lock()
+	validators := make([]*fieldValidator, len(f.validators))
+	copy(validators, f.validators)
+	f.validatorsMu.Unlock()
+
+	var errs ValidationError
+	for _, v := range validators {
+		errs = append(errs, v.validate()...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindMode string
+
+type bindTLS struct {
+	Version string `config:"version" default:"1.2" validate:"oneof=1.0 1.1 1.2 1.3"`
+}
+
+type bindCommon struct {
+	Name string `config:"name" validate:"required"`
+}
+
+type bindConfig struct {
+	bindCommon
+
+	DialTimeout time.Duration `config:"dial_timeout" default:"5s" usage:"dial timeout" validate:"min=1ms,max=1m"`
+	MaxConns    int           `config:"max_conns" default:"100" validate:"min=1"`
+	Hosts       []string      `config:"hosts" default:"a,b" validate:"required,oneof=a b c"`
+	Mode        bindMode      `config:"mode" default:"fast"`
+	Ratio       float64       `config:"ratio"`
+	TLS         bindTLS       `config:"tls"`
+	Retries     *struct {
+		Attempts uint `config:"attempts" default:"3"`
+	} `config:"retries"`
+
+	Ignored  string
+	Skipped  string `config:"-"`
+	internal string //nolint:structcheck,unused
+}
+
+func TestBind(t *testing.T) {
+	cfgPath, err := filepath.Abs("testdata/bind.yaml")
+	require.NoError(t, err)
+
+	f := NewFlagSet("bind", pflag.ContinueOnError)
+	cfg := &bindConfig{
+		Ratio: 0.5,
+	}
+	require.NoError(t, f.Bind("client", cfg))
+
+	flag := f.Lookup("client.dial_timeout")
+	require.NotNil(t, flag)
+	assert.Equal(t, "dial timeout", flag.Usage)
+	assert.Equal(t, "5s", flag.DefValue)
+	assert.Nil(t, f.Lookup("client.ignored"))
+	assert.Nil(t, f.Lookup("client.skipped"))
+
+	require.NoError(t, f.Init(&cfgPath, "--client.max_conns=70"))
+
+	assert.Equal(t, "billing", cfg.Name)
+	assert.Equal(t, 2*time.Second, cfg.DialTimeout)
+	assert.Equal(t, 70, cfg.MaxConns)
+	assert.Equal(t, []string{"b", "c"}, cfg.Hosts)
+	assert.Equal(t, bindMode("slow"), cfg.Mode)
+	assert.Equal(t, 0.5, cfg.Ratio)
+	assert.Equal(t, "1.3", cfg.TLS.Version)
+	assert.Equal(t, uint(3), cfg.Retries.Attempts)
+}
+
+func TestBind_Validation(t *testing.T) {
+	cfgPath, err := filepath.Abs("testdata/bind_invalid.yaml")
+	require.NoError(t, err)
+
+	f := NewFlagSet("bind_invalid", pflag.ContinueOnError)
+	cfg := &bindConfig{}
+	require.NoError(t, f.Bind("client", cfg))
+
+	err = f.Init(&cfgPath)
+	require.Error(t, err)
+
+	var verr ValidationError
+	require.True(t, errors.As(err, &verr))
+
+	names := make([]string, 0, len(verr))
+	for _, fe := range verr {
+		names = append(names, fe.Name+":"+fe.Rule)
+	}
+	assert.ElementsMatch(t, []string{
+		"client.name:required",
+		"client.dial_timeout:max",
+		"client.max_conns:min",
+		"client.hosts:oneof",
+		"client.tls.version:oneof",
+	}, names)
+
+	var fe *FieldError
+	assert.True(t, errors.As(err, &fe))
+}
+
+func TestBind_InvalidDefinition(t *testing.T) {
+	f := NewFlagSet("bind_definition", pflag.ContinueOnError)
+
+	assert.Error(t, f.Bind("", bindConfig{}))
+	assert.Error(t, f.Bind("", &struct {
+		Value map[string]string `config:"value"`
+	}{}))
+	assert.Error(t, f.Bind("", &struct {
+		Value int `config:"value" default:"abc"`
+	}{}))
+	assert.Error(t, f.Bind("", &struct {
+		Value int `config:"value" validate:"unknown"`
+	}{}))
+	assert.Error(t, f.Bind("", &struct {
+		Value time.Duration `config:"value" validate:"min=1"`
+	}{}))
+}
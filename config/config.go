@@ -17,12 +17,14 @@ var (
 )
 
 var (
-	configPath *string
+	configPath  *string
+	printConfig *bool
 )
 
 var (
 	sliceTypes = map[string]struct{}{
 		"int64Slice":    {},
+		"int32Slice":    {},
 		"intSlice":      {},
 		"stringSlice":   {},
 		"uintSlice":     {},
@@ -35,11 +37,21 @@ var (
 
 const (
 	typeRawData = "RawData"
+
+	flagConfig      = "config"
+	flagPrintConfig = "print-config"
 )
 
 func init() {
 	defaultFlagSet = NewFlagSet("default", pflag.ExitOnError)
-	configPath = defaultFlagSet.String("config", "", "Configuration file path.")
+	// NOTE: the global configuration is still available via the global viper instance.
+	defaultFlagSet.v = viper.GetViper()
+	configPath = new(string)
+	defaultFlagSet.Var(flagConfig, &configFilesValue{
+		f:    defaultFlagSet,
+		path: configPath,
+	}, "Configuration file path, repeat the flag to add overlays.")
+	printConfig = defaultFlagSet.Bool(flagPrintConfig, false, "Print the effective configuration and exit.")
 }
 
 func marshalBack(x interface{}, configType string) ([]byte, error) {
@@ -51,47 +63,48 @@ func marshalBack(x interface{}, configType string) ([]byte, error) {
 	return json.Marshal(x)
 }
 
-func handleRawData(value interface{}, flag *pflag.Flag, configType string) {
-	data, err := marshalBack(value, configType)
-	if err != nil {
-		panic(err)
+// sourceValue returns the value of the flag from the environment or the configuration files.
+//
+// Commandline arguments have the highest priority, so nothing is returned for the changed flags.
+func (f *FlagSet) sourceValue(flag *pflag.Flag) (string, bool) {
+	if flag.Changed || isConfigFilesFlag(flag) {
+		return "", false
 	}
-	_ = flag.Value.Set(string(data))
-}
 
-func parseFromConfig(flagSet *FlagSet, configPath string) {
-	viper.AutomaticEnv()
-
-	configType := parseConfigType(configPath)
-	viper.SetConfigType(configType)
-	flagSet.setConfigType(configType)
+	if v, ok := os.LookupEnv(f.EnvKey(flag.Name)); ok {
+		return v, true
+	}
 
-	err := viper.ReadInConfig()
-	if err != nil {
-		panic(err)
+	if f.configPath == "" {
+		return "", false
 	}
 
-	flagSet.VisitAll(func(flag *pflag.Flag) {
-		x := viper.Get(flag.Name)
-		if x == nil {
-			return
+	if flag.Value.Type() == typeRawData {
+		// NOTE: viper returns default values of the bound flags which are not encoded.
+		if !f.v.IsSet(flag.Name) {
+			return "", false
 		}
 
-		switch flag.Value.Type() {
-		case typeRawData:
-			handleRawData(x, flag, flagSet.configType)
-		default:
-			_ = flag.Value.Set(getFlagValue(flag))
+		data, err := marshalBack(f.v.Get(flag.Name), f.configType)
+		if err != nil {
+			panic(err)
 		}
-	})
+		return string(data), true
+	}
+
+	if f.v.Get(flag.Name) == nil {
+		return "", false
+	}
+
+	return f.getFlagValue(flag), true
 }
 
-func getFlagValue(flag *pflag.Flag) string {
+func (f *FlagSet) getFlagValue(flag *pflag.Flag) string {
 	if isTypeOfSlice(flag.Value.Type()) {
-		return strings.Join(viper.GetStringSlice(flag.Name), ",")
+		return strings.Join(f.v.GetStringSlice(flag.Name), ",")
 	}
 
-	return viper.GetString(flag.Name)
+	return f.v.GetString(flag.Name)
 }
 
 func isTypeOfSlice(flagType string) bool {
@@ -111,8 +124,16 @@ func isTypeOfSlice(flagType string) bool {
 // // 	config.InitOnce()
 // // }
 // It is advised to shutdown application if some error occurred.
+//
+// If the application is started with --print-config, InitOnce prints
+// the effective configuration and exits.
 func InitOnce() error {
-	return defaultFlagSet.Init(configPath, os.Args[1:]...)
+	err := defaultFlagSet.Init(configPath, os.Args[1:]...)
+	if *printConfig {
+		printConfigAndExit(err)
+	}
+
+	return err
 }
 
 // FilePath returns path to config file
@@ -473,9 +494,17 @@ func (f *FlagSet) RawData(name string, defValue []byte, description string) *[]b
 	return &dt.data
 }
 
-// SubConfigSuffixes gets all the configuration params having one given prefix.
+// SubConfigSuffixes gets all the global configuration params having one given prefix.
 func SubConfigSuffixes(prefix string) []string {
-	subConf := viper.GetStringMapStringSlice(prefix)
+	return defaultFlagSet.SubConfigSuffixes(prefix)
+}
+
+// SubConfigSuffixes gets all the configuration params having one given prefix.
+func (f *FlagSet) SubConfigSuffixes(prefix string) []string {
+	f.mu.RLock()
+	subConf := f.v.GetStringMapStringSlice(prefix)
+	f.mu.RUnlock()
+
 	suffixes := make([]string, 0, len(subConf))
 	for k := range subConf {
 		suffixes = append(suffixes, k)
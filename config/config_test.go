@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
@@ -112,3 +113,44 @@ func TestRawData(t *testing.T) {
 `),
 		*bytes)
 }
+
+func TestFlagSet_Isolated(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath1 := filepath.Join(dir, "first.yaml")
+	cfgPath2 := filepath.Join(dir, "second.yaml")
+	writeConfig(t, cfgPath1, "app:\n  name: first\n  workers: 1\n")
+	writeConfig(t, cfgPath2, "app:\n  name: second\n  workers: 2\n")
+
+	f1 := NewFlagSet("first", pflag.ContinueOnError)
+	name1 := f1.String("app.name", "", "")
+	workers1 := f1.Int("app.workers", 0, "")
+
+	f2 := NewFlagSet("second", pflag.ContinueOnError)
+	name2 := f2.String("app.name", "", "")
+	workers2 := f2.Int("app.workers", 0, "")
+
+	require.NoError(t, f1.Init(&cfgPath1))
+	require.NoError(t, f2.Init(&cfgPath2, "--app.workers=5"))
+
+	assert.Equal(t, "first", *name1)
+	assert.Equal(t, 1, *workers1)
+	assert.Equal(t, "second", *name2)
+	assert.Equal(t, 5, *workers2)
+
+	require.NoError(t, f1.Reload())
+	assert.Equal(t, "first", *name1)
+	assert.Equal(t, []string{"name", "workers"}, sortedStrings(f2.SubConfigSuffixes("app")))
+}
+
+func TestFlagSetOrDefault(t *testing.T) {
+	f := NewFlagSet("or_default", pflag.ContinueOnError)
+
+	assert.Same(t, defaultFlagSet, FlagSetOrDefault())
+	assert.Same(t, defaultFlagSet, FlagSetOrDefault(nil))
+	assert.Same(t, f, FlagSetOrDefault(nil, f))
+}
+
+func sortedStrings(s []string) []string {
+	sort.Strings(s)
+	return s
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// Source is a source of the configuration param value.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Dump formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Entry describes the effective value of the configuration param.
+type Entry struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`
+	Source Source `json:"source" yaml:"source"`
+	Usage  string `json:"usage,omitempty" yaml:"usage,omitempty"`
+}
+
+// Entries returns the effective global configuration sorted by keys.
+func Entries() []Entry {
+	return defaultFlagSet.Entries()
+}
+
+// Entries returns the effective configuration sorted by keys.
+//
+// Values of secret params are replaced with RedactedValue.
+func (f *FlagSet) Entries() []Entry {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var entries []Entry
+	f.VisitAll(func(flag *pflag.Flag) {
+		entries = append(entries, Entry{
+			Key:    flag.Name,
+			Value:  displayValue(flag),
+			Source: f.source(flag),
+			Usage:  flag.Usage,
+		})
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries
+}
+
+// Dump returns the effective global configuration in the given format.
+func Dump(format string) ([]byte, error) {
+	return defaultFlagSet.Dump(format)
+}
+
+// Dump returns the effective configuration in the given format:
+// FormatText, FormatJSON or FormatYAML.
+//
+// Values of secret params are replaced with RedactedValue.
+func (f *FlagSet) Dump(format string) ([]byte, error) {
+	entries := f.Entries()
+
+	switch format {
+	case FormatText:
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		for _, e := range entries {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Key, e.Value, e.Source, e.Usage)
+		}
+		_ = w.Flush()
+		return buf.Bytes(), nil
+	case FormatJSON:
+		return json.MarshalIndent(entries, "", "  ")
+	case FormatYAML, "yml":
+		return yaml.Marshal(entries)
+	default:
+		return nil, fmt.Errorf("unknown dump format: %s", format)
+	}
+}
+
+// Handler returns the HTTP handler dumping the global configuration.
+func Handler() http.Handler {
+	return defaultFlagSet.Handler()
+}
+
+// Handler returns the HTTP handler dumping the configuration.
+//
+// The format is passed in the "format" query param, FormatJSON is used by default.
+func (f *FlagSet) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = FormatJSON
+		}
+
+		data, err := f.Dump(format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch format {
+		case FormatJSON:
+			w.Header().Set("Content-Type", "application/json")
+		case FormatYAML, "yml":
+			w.Header().Set("Content-Type", "application/x-yaml")
+		default:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		_, _ = w.Write(data)
+	})
+}
+
+// source returns the source of the flag value
+// according to the priority of the sources.
+func (f *FlagSet) source(flag *pflag.Flag) Source {
+	if flag.Changed {
+		return SourceFlag
+	}
+	if flag.Name == flagConfig && f.configFromEnv {
+		return SourceEnv
+	}
+	if _, ok := os.LookupEnv(f.EnvKey(flag.Name)); ok {
+		return SourceEnv
+	}
+	if f.configPath != "" && f.v.IsSet(flag.Name) {
+		return SourceFile
+	}
+
+	return SourceDefault
+}
+
+// displayValue returns the value of the flag safe for printing.
+func displayValue(flag *pflag.Flag) string {
+	if isSecret(flag) && flag.Value.String() != "" {
+		return RedactedValue
+	}
+
+	return flag.Value.String()
+}
+
+// printConfigAndExit prints the effective global configuration to stdout
+// and exits, err is printed to stderr.
+func printConfigAndExit(err error) {
+	data, dumpErr := Dump(FormatText)
+	if dumpErr != nil {
+		err = dumpErr
+	}
+	_, _ = os.Stdout.Write(data)
+
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, cfgPath, "dump:\n  addr: :8080\n  password: qwerty\n  level: info\n")
+	t.Setenv("DUMP_LEVEL_ENV", "warn")
+
+	f := NewFlagSet("dump", pflag.ContinueOnError)
+	f.String("dump.addr", ":80", "listen address")
+	f.Secret("dump.password", "", "user password")
+	f.String("dump.level", "debug", "log level")
+	f.Int("dump.workers", 4, "number of workers")
+	f.String("dump_level_env", "", "log level from env")
+
+	require.NoError(t, f.Init(&cfgPath, "--dump.workers=8"))
+
+	assert.Equal(t, []Entry{
+		{Key: "dump.addr", Value: ":8080", Source: SourceFile, Usage: "listen address"},
+		{Key: "dump.level", Value: "info", Source: SourceFile, Usage: "log level"},
+		{Key: "dump.password", Value: RedactedValue, Source: SourceFile, Usage: "user password"},
+		{Key: "dump.workers", Value: "8", Source: SourceFlag, Usage: "number of workers"},
+		{Key: "dump_level_env", Value: "warn", Source: SourceEnv, Usage: "log level from env"},
+	}, f.Entries())
+
+	for _, format := range []string{FormatText, FormatJSON, FormatYAML} {
+		data, err := f.Dump(format)
+		require.NoError(t, err, format)
+		assert.NotContains(t, string(data), "qwerty", format)
+		assert.Contains(t, string(data), "dump.workers", format)
+	}
+
+	_, err := f.Dump("xml")
+	assert.Error(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	f := NewFlagSet("dump_handler", pflag.ContinueOnError)
+	f.Int("workers", 4, "number of workers")
+	require.NoError(t, f.Init(nil))
+
+	rec := httptest.NewRecorder()
+	f.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var entries []Entry
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	assert.Equal(t, []Entry{
+		{Key: "workers", Value: "4", Source: SourceDefault, Usage: "number of workers"},
+	}, entries)
+
+	rec = httptest.NewRecorder()
+	f.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config?format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,39 @@
+package config
+
+import "strings"
+
+var envReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// SetEnvPrefix sets the prefix of environment variables overriding the global configuration params.
+//
+// It must be called before InitOnce.
+func SetEnvPrefix(prefix string) {
+	defaultFlagSet.SetEnvPrefix(prefix)
+}
+
+// SetEnvPrefix sets the prefix of environment variables overriding the params.
+//
+// It must be called before Init.
+func (f *FlagSet) SetEnvPrefix(prefix string) {
+	f.envPrefix = strings.TrimSuffix(prefix, "_")
+}
+
+// EnvKey returns the name of the environment variable overriding the global configuration param.
+func EnvKey(name string) string {
+	return defaultFlagSet.EnvKey(name)
+}
+
+// EnvKey returns the name of the environment variable overriding the param:
+// the prefix and the name joined with underscore, uppercased, dots and dashes
+// are replaced with underscores.
+//
+// For example, with the prefix "app" the param "external.retries.max_attempts"
+// is overridden by APP_EXTERNAL_RETRIES_MAX_ATTEMPTS.
+func (f *FlagSet) EnvKey(name string) string {
+	key := name
+	if f.envPrefix != "" {
+		key = f.envPrefix + "_" + name
+	}
+
+	return strings.ToUpper(envReplacer.Replace(key))
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvKey(t *testing.T) {
+	f := NewFlagSet("env_key", pflag.ContinueOnError)
+	assert.Equal(t, "EXTERNAL_RETRIES_MAX_ATTEMPTS", f.EnvKey("external.retries.max_attempts"))
+
+	f.SetEnvPrefix("app_")
+	assert.Equal(t, "APP_EXTERNAL_RETRIES_MAX_ATTEMPTS", f.EnvKey("external.retries.max_attempts"))
+	assert.Equal(t, "APP_PRINT_CONFIG", f.EnvKey("print-config"))
+}
+
+func TestEnv(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, cfgPath, "external:\n  retries:\n    max_attempts: 3\n    wait: 1s\n  hosts: [a]\n  name: file\n")
+	t.Setenv("ENVTEST_EXTERNAL_RETRIES_MAX_ATTEMPTS", "5")
+	t.Setenv("ENVTEST_EXTERNAL_HOSTS", "b,c")
+	t.Setenv("ENVTEST_EXTERNAL_NAME", "env")
+	t.Setenv("ENVTEST_EXTERNAL_TIMEOUT", "2s")
+
+	f := NewFlagSet("env", pflag.ContinueOnError)
+	f.SetEnvPrefix("envtest")
+	attempts := f.Int("external.retries.max_attempts", 1, "")
+	wait := f.Duration("external.retries.wait", 0, "")
+	hosts := f.StringSlice("external.hosts", nil, "")
+	name := f.String("external.name", "", "")
+	timeout := f.Duration("external.timeout", 0, "")
+	workers := f.Int("external.workers", 4, "")
+	f.OnChange("external.retries", func() {})
+
+	require.NoError(t, f.Init(&cfgPath, "--external.name=flag"))
+	assert.Equal(t, 5, *attempts)
+	assert.Equal(t, "1s", wait.String())
+	assert.Equal(t, []string{"b", "c"}, *hosts)
+	assert.Equal(t, "flag", *name)
+	assert.Equal(t, "2s", timeout.String())
+	assert.Equal(t, 4, *workers)
+
+	sources := make(map[string]Source)
+	for _, e := range f.Entries() {
+		sources[e.Key] = e.Source
+	}
+	assert.Equal(t, map[string]Source{
+		"external.retries.max_attempts": SourceEnv,
+		"external.retries.wait":         SourceFile,
+		"external.hosts":                SourceEnv,
+		"external.name":                 SourceFlag,
+		"external.timeout":              SourceEnv,
+		"external.workers":              SourceDefault,
+	}, sources)
+
+	// NOTE: environment keeps priority over the reloaded file.
+	writeConfig(t, cfgPath, "external:\n  retries:\n    max_attempts: 10\n    wait: 3s\n")
+	require.NoError(t, f.Reload())
+	assert.Equal(t, 5, *attempts)
+	assert.Equal(t, "3s", wait.String())
+}
+
+func TestEnv_NoConfigFile(t *testing.T) {
+	t.Setenv("ENVTEST_NO_FILE_LOG_LEVEL", "warn")
+
+	f := NewFlagSet("env_no_file", pflag.ContinueOnError)
+	f.SetEnvPrefix("ENVTEST_NO_FILE")
+	level := f.String("log.level", "info", "")
+
+	require.NoError(t, f.Init(nil))
+	assert.Equal(t, "warn", *level)
+}
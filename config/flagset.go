@@ -2,7 +2,6 @@ package config
 
 import (
 	"os"
-	"strings"
 	"sync"
 
 	"github.com/spf13/viper"
@@ -14,12 +13,29 @@ const envConfig = "CONFIG"
 
 // A FlagSet represents a set of defined flags.
 //
-// Current FlagSet is just a small wrapper above pflag.FlagSet
+// Current FlagSet is just a small wrapper above pflag.FlagSet.
+// Each FlagSet owns its viper instance, so several configurations
+// can be used in one process.
 type FlagSet struct {
 	*pflag.FlagSet
-	once       sync.Once
-	configType string
-	configPath string
+	v             *viper.Viper
+	once          sync.Once
+	configType    string
+	configPath    string
+	configFromEnv bool
+	overlays      []string
+	envPrefix     string
+
+	// mu guards the viper instance, definitions and values of flags,
+	// subscriptions and dynamic values.
+	mu       sync.RWMutex
+	reloadMu sync.Mutex
+	subs     []*subscription
+	dynamics []refresher
+	sections []string
+
+	validatorsMu sync.Mutex
+	validators   []*fieldValidator
 }
 
 // NewFlagSet creates new FlagSet.
@@ -27,9 +43,29 @@ func NewFlagSet(name string, errorHandling pflag.ErrorHandling) *FlagSet {
 	fs := pflag.NewFlagSet(name, errorHandling)
 	return &FlagSet{
 		FlagSet: fs,
+		v:       viper.New(),
 	}
 }
 
+// FlagSetOrDefault returns the first non-nil FlagSet or the global one.
+//
+// It lets configuration helpers accept an optional FlagSet:
+//
+//	func NewConfig(prefix string, set ...*config.FlagSet) func() *Config {
+//		fs := config.FlagSetOrDefault(set...)
+//		addr := fs.String(prefix+"addr", "", "address")
+//		...
+//	}
+func FlagSetOrDefault(sets ...*FlagSet) *FlagSet {
+	for _, f := range sets {
+		if f != nil {
+			return f
+		}
+	}
+
+	return defaultFlagSet
+}
+
 func (f *FlagSet) setConfigType(typ string) {
 	f.configType = typ
 }
@@ -40,12 +76,12 @@ func (f *FlagSet) setConfigType(typ string) {
 func (f *FlagSet) Init(configPath *string, cmdlineArgs ...string) error {
 	var err error
 	f.once.Do(func() {
-		err = viper.BindPFlags(defaultFlagSet.FlagSet)
-		if err != nil {
-			return
+		f.mu.Lock()
+		err = f.v.BindPFlags(f.FlagSet)
+		if err == nil {
+			err = f.Parse(cmdlineArgs)
 		}
-
-		err = f.Parse(cmdlineArgs)
+		f.mu.Unlock()
 		if err != nil {
 			return
 		}
@@ -57,36 +93,63 @@ func (f *FlagSet) Init(configPath *string, cmdlineArgs ...string) error {
 		if f.configPath == "" {
 			if path, ok := os.LookupEnv(envConfig); ok {
 				f.configPath = path
+				f.configFromEnv = true
+				if flag := f.Lookup(flagConfig); flag != nil {
+					_ = flag.Value.Set(path)
+				}
 			}
 		}
 
-		if f.configPath != "" {
-			viper.SetConfigFile(f.configPath)
-			// NOTE(a.petrukhin): commandline arguments have more priority than config's ones.
-			parseFromConfig(f, f.configPath)
+		if len(f.overlays) == 0 {
+			if paths, ok := os.LookupEnv(envConfigOverlays); ok {
+				f.overlays = splitPaths(paths)
+			}
 		}
-	})
 
-	return err
-}
+		// NOTE: the first overlay becomes the base file if the last one is not set.
+		if f.configPath == "" && len(f.overlays) > 0 {
+			f.configPath, f.overlays = f.overlays[0], f.overlays[1:]
+		}
 
-// ReInit reinitializes FlagSet.
-func (f *FlagSet) ReInit(prefix string) {
-	f.VisitAll(func(flag *pflag.Flag) {
-		if !strings.HasPrefix(flag.Name, prefix) {
-			return
+		if f.configPath != "" {
+			f.setConfigType(parseConfigType(f.configPath))
+			err = f.readConfig()
+			if err != nil {
+				return
+			}
 		}
 
-		x := viper.Get(flag.Name)
-		if x == nil {
+		// NOTE(a.petrukhin): commandline arguments have more priority than config's ones.
+		_, err = f.apply("")
+		if err != nil {
 			return
 		}
 
-		switch flag.Value.Type() {
-		case typeRawData:
-			handleRawData(x, flag, f.configType)
-		default:
-			_ = flag.Value.Set(getFlagValue(flag))
+		f.mu.Lock()
+		err = f.resolveSecrets()
+		f.refreshDynamics()
+		f.mu.Unlock()
+		if err != nil {
+			return
 		}
+
+		err = f.validate()
 	})
+
+	return err
+}
+
+// ReInit reinitializes FlagSet params having the given prefix
+// and notifies the subscribers of the changed params.
+//
+// Params with unresolvable secrets keep their current values.
+func (f *FlagSet) ReInit(prefix string) {
+	f.reloadMu.Lock()
+	defer f.reloadMu.Unlock()
+
+	changed, err := f.apply(prefix)
+	if err != nil {
+		defOnReloadError(err)
+	}
+	f.notify(changed)
 }
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const envConfigOverlays = "CONFIG_OVERLAYS"
+
+// configFilesValue is a value of the flag setting the configuration file.
+//
+// The first value is the path to the base file, the next ones are overlays.
+type configFilesValue struct {
+	f    *FlagSet
+	path *string
+	set  bool
+}
+
+func (v *configFilesValue) String() string {
+	if v.path == nil {
+		return ""
+	}
+
+	return strings.Join(append([]string{*v.path}, v.f.overlays...), ",")
+}
+
+func (v *configFilesValue) Set(s string) error {
+	if !v.set {
+		*v.path = s
+		v.set = true
+		return nil
+	}
+
+	v.f.overlays = append(v.f.overlays, s)
+	return nil
+}
+
+func (v *configFilesValue) Type() string {
+	return "stringArray"
+}
+
+// isConfigFilesFlag reports whether the flag sets the configuration files
+// and must not be set from the configuration itself.
+func isConfigFilesFlag(flag *pflag.Flag) bool {
+	_, ok := flag.Value.(*configFilesValue)
+	return ok
+}
+
+// OverlayPaths returns paths to the overlays of the global configuration file.
+func OverlayPaths() []string {
+	return defaultFlagSet.OverlayPaths()
+}
+
+// OverlayPaths returns paths to the overlays of the configuration file.
+func (f *FlagSet) OverlayPaths() []string {
+	paths := make([]string, len(f.overlays))
+	copy(paths, f.overlays)
+	return paths
+}
+
+// AddOverlays adds files which are deep-merged into the configuration file in the given order.
+//
+// It must be called before Init.
+func (f *FlagSet) AddOverlays(paths ...string) {
+	f.overlays = append(f.overlays, paths...)
+}
+
+// configFiles returns paths to the configuration file and its overlays.
+func (f *FlagSet) configFiles() []string {
+	if f.configPath == "" {
+		return nil
+	}
+
+	return append([]string{f.configPath}, f.overlays...)
+}
+
+// readConfig reads the configuration file, deep-merges the overlays into it
+// and replaces the configuration of the FlagSet viper instance with the result.
+//
+// The configuration of viper stays unchanged if any of the files is invalid.
+func (f *FlagSet) readConfig() error {
+	merged := make(map[string]interface{})
+	for _, path := range f.configFiles() {
+		v := viper.New()
+		v.SetConfigFile(path)
+		v.SetConfigType(parseConfigType(path))
+		err := v.ReadInConfig()
+		if err != nil {
+			return fmt.Errorf("failed to read config %s: %w", path, err)
+		}
+
+		mergeSettings(merged, v.AllSettings())
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// NOTE: viper can not replace the configuration with a map,
+	// so reset it with an empty document and merge the map into it.
+	f.v.SetConfigFile(f.configPath)
+	f.v.SetConfigType("yaml")
+	err := f.v.ReadConfig(strings.NewReader(""))
+	if err != nil {
+		return err
+	}
+
+	return f.v.MergeConfigMap(merged)
+}
+
+// mergeSettings deep-merges src into dst.
+//
+// Nested maps are merged, other values including slices are replaced.
+func mergeSettings(dst, src map[string]interface{}) {
+	for k, sv := range src {
+		srcMap, ok := sv.(map[string]interface{})
+		if !ok {
+			dst[k] = sv
+			continue
+		}
+
+		dstMap, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dstMap = make(map[string]interface{})
+			dst[k] = dstMap
+		}
+		mergeSettings(dstMap, srcMap)
+	}
+}
+
+func splitPaths(s string) []string {
+	var paths []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	baseConfig = `
+mysql:
+  addr: localhost:3306
+  user: app
+  timeouts:
+    read: 1s
+    write: 1s
+hosts: [a, b, c]
+limits:
+  rps: 100
+  burst: 10
+`
+	overlayConfig = `{
+  "mysql": {
+    "addr": "db.msk:3306",
+    "timeouts": {"write": "3s"}
+  },
+  "hosts": ["d"],
+  "limits": {"burst": 20}
+}`
+	overlayConfig2 = `
+mysql:
+  user: msk
+`
+)
+
+func TestOverlays(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yml")
+	overlay := filepath.Join(dir, "dc-msk.json")
+	overlay2 := filepath.Join(dir, "dc-msk-user.yml")
+	writeConfig(t, base, baseConfig)
+	writeConfig(t, overlay, overlayConfig)
+	writeConfig(t, overlay2, overlayConfig2)
+
+	tests := []struct {
+		name string
+		init func(f *FlagSet) error
+	}{
+		{
+			name: "Flags",
+			init: func(f *FlagSet) error {
+				var path string
+				f.Var(flagConfig, &configFilesValue{f: f, path: &path}, "")
+				return f.Init(&path, "--config", base, "--config", overlay, "--config="+overlay2)
+			},
+		},
+		{
+			name: "Env",
+			init: func(f *FlagSet) error {
+				t.Setenv(envConfigOverlays, overlay+", "+overlay2)
+				return f.Init(&base)
+			},
+		},
+		{
+			name: "AddOverlays",
+			init: func(f *FlagSet) error {
+				f.AddOverlays(overlay, overlay2)
+				return f.Init(&base)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlagSet("overlays"+tt.name, pflag.ContinueOnError)
+			addr := f.String("mysql.addr", "", "")
+			user := f.String("mysql.user", "", "")
+			readTimeout := f.Duration("mysql.timeouts.read", 0, "")
+			writeTimeout := f.Duration("mysql.timeouts.write", 0, "")
+			hosts := f.StringSlice("hosts", nil, "")
+			limits := f.RawData("limits", nil, "")
+
+			require.NoError(t, tt.init(f))
+			assert.Equal(t, base, f.configPath)
+			assert.Equal(t, []string{overlay, overlay2}, f.OverlayPaths())
+
+			assert.Equal(t, "db.msk:3306", *addr)
+			assert.Equal(t, "msk", *user)
+			assert.Equal(t, "1s", readTimeout.String())
+			assert.Equal(t, "3s", writeTimeout.String())
+			assert.Equal(t, []string{"d"}, *hosts)
+
+			var gotLimits map[string]int
+			require.NoError(t, yaml.Unmarshal(*limits, &gotLimits))
+			assert.Equal(t, map[string]int{"rps": 100, "burst": 20}, gotLimits)
+		})
+	}
+}
+
+func TestOverlays_Reload(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yml")
+	overlay := filepath.Join(dir, "overlay.yml")
+	writeConfig(t, base, "log:\n  level: info\n  format: json\n")
+	writeConfig(t, overlay, "log:\n  level: warn\n")
+
+	f := NewFlagSet("overlays_reload", pflag.ContinueOnError)
+	level := NewDynamicIn(f, f.String("log.level", "", ""))
+	format := NewDynamicIn(f, f.String("log.format", "", ""))
+	f.AddOverlays(overlay)
+	require.NoError(t, f.Init(&base))
+	assert.Equal(t, "warn", level.Load())
+	assert.Equal(t, "json", format.Load())
+
+	writeConfig(t, overlay, "log:\n  level: error\n")
+	require.NoError(t, f.Reload())
+	assert.Equal(t, "error", level.Load())
+	assert.Equal(t, "json", format.Load())
+
+	// NOTE: invalid overlay keeps the current values.
+	writeConfig(t, overlay, "log: [")
+	require.Error(t, f.Reload())
+	assert.Equal(t, "error", level.Load())
+}
@@ -0,0 +1,514 @@
+package config
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/pflag"
+)
+
+var (
+	// ErrNoConfigFile is returned when the configuration file is not set.
+	ErrNoConfigFile = errors.New("configuration file is not set")
+)
+
+var (
+	defOnReloadError = func(err error) {
+		log.Printf("[config] reload error: %s", err)
+	}
+)
+
+type subscription struct {
+	prefix string
+	fn     func()
+}
+
+func (s *subscription) matches(name string) bool {
+	return s.prefix == "" || name == s.prefix || strings.HasPrefix(name, SanitizePrefix(s.prefix))
+}
+
+// refresher is a value refreshed after the configuration is changed.
+type refresher interface {
+	refresh()
+	addr() uintptr
+}
+
+// Dynamic is a configuration param which can be safely read
+// while the configuration is reloaded.
+//
+// Pointers returned by Int, Duration, String and other functions are not changed
+// by Reload and Watch unless the param is wrapped by Dynamic, subscribed by OnChange
+// or belongs to Section, so reading them concurrently with reloads is safe.
+// Dynamic is the supported way to read the reloaded params from other goroutines.
+type Dynamic[T any] struct {
+	ptr *T
+	v   atomic.Pointer[T]
+}
+
+// NewDynamic wraps the param of the global configuration.
+//
+// ptr must be the address returned by one of Int, Duration, String and other functions.
+func NewDynamic[T any](ptr *T) *Dynamic[T] {
+	return NewDynamicIn(defaultFlagSet, ptr)
+}
+
+// NewDynamicIn wraps the param of the given FlagSet.
+func NewDynamicIn[T any](f *FlagSet, ptr *T) *Dynamic[T] {
+	d := &Dynamic[T]{
+		ptr: ptr,
+	}
+
+	f.mu.Lock()
+	d.refresh()
+	f.dynamics = append(f.dynamics, d)
+	f.mu.Unlock()
+
+	return d
+}
+
+func (d *Dynamic[T]) refresh() {
+	v := *d.ptr
+	d.v.Store(&v)
+}
+
+func (d *Dynamic[T]) addr() uintptr {
+	return reflect.ValueOf(d.ptr).Pointer()
+}
+
+// Load returns the current value of the param.
+func (d *Dynamic[T]) Load() T {
+	return *d.v.Load()
+}
+
+// OnChange subscribes fn to changes of the global configuration params having the given prefix.
+//
+// Empty prefix subscribes to all the params.
+func OnChange(prefix string, fn func()) {
+	defaultFlagSet.OnChange(prefix, fn)
+}
+
+// OnChange subscribes fn to changes of the params having the given prefix.
+//
+// fn is called once per reload after all the changed values are applied.
+// Callbacks are called synchronously one by one, so the values are not
+// changed while the callback is running.
+func (f *FlagSet) OnChange(prefix string, fn func()) {
+	f.mu.Lock()
+	f.subs = append(f.subs, &subscription{
+		prefix: prefix,
+		fn:     fn,
+	})
+	f.mu.Unlock()
+}
+
+// Reload re-reads the global configuration file and applies the changed values.
+func Reload() error {
+	return defaultFlagSet.Reload()
+}
+
+// Reload re-reads the configuration file with its overlays and applies the changed values.
+//
+// Only the params wrapped by Dynamic, subscribed by OnChange or belonging to Section
+// are changed, other params keep the values set by Init, because they may be read
+// concurrently through the pointers returned by Int, String and other functions.
+// Commandline arguments keep priority over the values from the file.
+// Params removed from the file keep their current values.
+// If any of the files is invalid or the new values of the bound params
+// do not pass validation, the current values stay unchanged.
+func (f *FlagSet) Reload() error {
+	if f.configPath == "" {
+		return ErrNoConfigFile
+	}
+
+	f.reloadMu.Lock()
+	defer f.reloadMu.Unlock()
+
+	err := f.readConfig()
+	if err != nil {
+		return err
+	}
+
+	changed, err := f.applyWatched()
+	f.notify(changed)
+	return err
+}
+
+// paramChange describes the param changed by set.
+type paramChange struct {
+	flag *pflag.Flag
+	old  []string
+}
+
+// apply sets values from the environment and the configuration files to the params
+// having the given prefix and returns names of the changed params.
+//
+// Params with unresolvable secrets keep their current values.
+func (f *FlagSet) apply(prefix string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	changes, err := f.set(prefix, nil)
+	f.refreshDynamics()
+	return changedNames(changes), err
+}
+
+// applyWatched sets the new values of the watched params and returns names of the changed params.
+//
+// If the new values do not pass validation, the previous values are restored.
+func (f *FlagSet) applyWatched() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	changes, err := f.set("", f.watched)
+	if verr := f.validateLocked(); verr != nil {
+		for _, c := range changes {
+			restoreFlagValue(c.flag, c.old)
+		}
+		return nil, verr
+	}
+
+	f.refreshDynamics()
+	return changedNames(changes), err
+}
+
+// watched reports whether the changes of the param are expected by its readers.
+//
+// It MUST be called under mu.
+func (f *FlagSet) watched(flag *pflag.Flag) bool {
+	for _, s := range f.subs {
+		if s.matches(flag.Name) {
+			return true
+		}
+	}
+
+	for _, prefix := range f.sections {
+		if strings.HasPrefix(flag.Name, prefix) {
+			return true
+		}
+	}
+
+	addr := valueAddr(flag.Value)
+	for _, d := range f.dynamics {
+		if d.addr() == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+// refreshDynamics MUST be called under mu.
+func (f *FlagSet) refreshDynamics() {
+	for _, d := range f.dynamics {
+		d.refresh()
+	}
+}
+
+// set sets values from the environment and the configuration files to the params
+// having the given prefix and accepted by filter. It MUST be called under mu.
+func (f *FlagSet) set(prefix string, filter func(flag *pflag.Flag) bool) ([]paramChange, error) {
+	var (
+		changes []paramChange
+		errs    []string
+	)
+	f.VisitAll(func(flag *pflag.Flag) {
+		if !strings.HasPrefix(flag.Name, prefix) {
+			return
+		}
+		if filter != nil && !filter(flag) {
+			return
+		}
+
+		value, ok := f.sourceValue(flag)
+		if !ok {
+			return
+		}
+
+		old := flag.Value.String()
+		value, _, err := resolveValue(flag, value)
+		if err != nil {
+			errs = append(errs, err.Error())
+			return
+		}
+
+		prev := flagValue(flag)
+		_ = setFlagValue(flag, value)
+
+		if flag.Value.String() != old {
+			changes = append(changes, paramChange{
+				flag: flag,
+				old:  prev,
+			})
+		}
+	})
+
+	if len(errs) > 0 {
+		return changes, fmt.Errorf("config: %s", strings.Join(errs, "; "))
+	}
+
+	return changes, nil
+}
+
+func changedNames(changes []paramChange) []string {
+	names := make([]string, 0, len(changes))
+	for _, c := range changes {
+		names = append(names, c.flag.Name)
+	}
+	return names
+}
+
+// setFlagValue sets the value of the flag.
+//
+// Values of slices are replaced instead of being appended as on repeated Set.
+func setFlagValue(flag *pflag.Flag, value string) error {
+	sv, ok := flag.Value.(pflag.SliceValue)
+	if !ok {
+		return flag.Value.Set(value)
+	}
+
+	items := []string{}
+	if value != "" {
+		var err error
+		items, err = csv.NewReader(strings.NewReader(value)).Read()
+		if err != nil {
+			return err
+		}
+	}
+
+	return sv.Replace(items)
+}
+
+// flagValue returns the value of the flag which can be restored by restoreFlagValue.
+func flagValue(flag *pflag.Flag) []string {
+	if sv, ok := flag.Value.(pflag.SliceValue); ok {
+		return sv.GetSlice()
+	}
+
+	return []string{flag.Value.String()}
+}
+
+// restoreFlagValue sets the value returned by flagValue.
+func restoreFlagValue(flag *pflag.Flag, value []string) {
+	if sv, ok := flag.Value.(pflag.SliceValue); ok {
+		_ = sv.Replace(value)
+		return
+	}
+
+	_ = flag.Value.Set(value[0])
+}
+
+// valueAddr returns the address of the variable the flag value is stored in.
+func valueAddr(v pflag.Value) uintptr {
+	if kv, ok := v.(*kindValue); ok {
+		return kv.v.Addr().Pointer()
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr {
+		return 0
+	}
+
+	// NOTE: pflag slice values keep the pointer to the variable in the value field.
+	if ev := rv.Elem(); ev.Kind() == reflect.Struct {
+		if pv := ev.FieldByName("value"); pv.IsValid() && pv.Kind() == reflect.Ptr {
+			return pv.Pointer()
+		}
+	}
+
+	return rv.Pointer()
+}
+
+// notify calls subscriptions matching the changed params.
+func (f *FlagSet) notify(changed []string) {
+	if len(changed) == 0 {
+		return
+	}
+
+	f.mu.RLock()
+	subs := make([]*subscription, len(f.subs))
+	copy(subs, f.subs)
+	f.mu.RUnlock()
+
+	for _, s := range subs {
+		for _, name := range changed {
+			if s.matches(name) {
+				s.fn()
+				break
+			}
+		}
+	}
+}
+
+type watchOptions struct {
+	pollInterval  time.Duration
+	onReloadError func(error)
+}
+
+// WatchOption is an optional Watch parameter.
+type WatchOption func(*watchOptions)
+
+// WithPollInterval makes Watch poll the file modification time with the given interval
+// instead of using filesystem notifications.
+func WithPollInterval(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.pollInterval = d
+	}
+}
+
+// WithOnReloadError sets the callback called when the changed file can not be applied.
+//
+// By default, the error is logged.
+func WithOnReloadError(fn func(error)) WatchOption {
+	return func(o *watchOptions) {
+		o.onReloadError = fn
+	}
+}
+
+// Watch watches the global configuration file with its overlays and reloads it on each change.
+//
+// It must be called after InitOnce. Watching stops when ctx is done.
+func Watch(ctx context.Context, opts ...WatchOption) error {
+	return defaultFlagSet.Watch(ctx, opts...)
+}
+
+// Watch watches the configuration file with its overlays and reloads it on each change.
+//
+// It must be called after Init. Watching stops when ctx is done.
+func (f *FlagSet) Watch(ctx context.Context, opts ...WatchOption) error {
+	if f.configPath == "" {
+		return ErrNoConfigFile
+	}
+
+	o := &watchOptions{
+		onReloadError: defOnReloadError,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.pollInterval > 0 {
+		return f.poll(ctx, o)
+	}
+
+	return f.watchNotify(ctx, o)
+}
+
+func (f *FlagSet) reload(o *watchOptions) {
+	if err := f.Reload(); err != nil && o.onReloadError != nil {
+		o.onReloadError(err)
+	}
+}
+
+// watchNotify watches directories of the files to handle editors and
+// orchestrators replacing the files or the symlinks to them.
+func (f *FlagSet) watchNotify(ctx context.Context, o *watchOptions) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	realPaths := make(map[string]string)
+	for _, file := range f.configFiles() {
+		file = filepath.Clean(file)
+		err = watcher.Add(filepath.Dir(file))
+		if err != nil {
+			_ = watcher.Close()
+			return err
+		}
+		realPaths[file], _ = filepath.EvalSymlinks(file)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				changed := false
+				for file, realPath := range realPaths {
+					curPath, _ := filepath.EvalSymlinks(file)
+					written := filepath.Clean(ev.Name) == file && ev.Op&(fsnotify.Write|fsnotify.Create) != 0
+					relinked := curPath != "" && curPath != realPath
+					if written || relinked {
+						realPaths[file] = curPath
+						changed = true
+					}
+				}
+				if changed {
+					f.reload(o)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				if o.onReloadError != nil {
+					o.onReloadError(err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (f *FlagSet) poll(ctx context.Context, o *watchOptions) error {
+	files := f.configFiles()
+	last := make(map[string]os.FileInfo, len(files))
+	for _, file := range files {
+		fi, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		last[file] = fi
+	}
+
+	go func() {
+		ticker := time.NewTicker(o.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				changed := false
+				for _, file := range files {
+					cur, err := os.Stat(file)
+					if err != nil {
+						if o.onReloadError != nil {
+							o.onReloadError(err)
+						}
+						continue
+					}
+
+					prev := last[file]
+					if cur.ModTime() != prev.ModTime() || cur.Size() != prev.Size() {
+						last[file] = cur
+						changed = true
+					}
+				}
+				if changed {
+					f.reload(o)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, path, data string) {
+	t.Helper()
+
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, []byte(data), 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func TestReload(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, cfgPath, "graceful:\n  shutdown_timeout: 1s\nlog:\n  level: info\nhosts: [a, b]\n")
+
+	f := NewFlagSet("reload", pflag.ContinueOnError)
+	timeout := f.Duration("graceful.shutdown_timeout", 0, "")
+	level := f.String("log.level", "debug", "")
+	hosts := f.StringSlice("hosts", nil, "")
+	dynTimeout := NewDynamicIn(f, timeout)
+
+	var gracefulCalls, logCalls, allCalls int32
+	f.OnChange("graceful", func() {
+		atomic.AddInt32(&gracefulCalls, 1)
+	})
+	f.OnChange("log", func() {
+		atomic.AddInt32(&logCalls, 1)
+	})
+	f.OnChange("", func() {
+		atomic.AddInt32(&allCalls, 1)
+	})
+
+	require.NoError(t, f.Init(&cfgPath))
+	assert.Equal(t, time.Second, *timeout)
+	assert.Equal(t, time.Second, dynTimeout.Load())
+	assert.Equal(t, "info", *level)
+	assert.Equal(t, []string{"a", "b"}, *hosts)
+
+	writeConfig(t, cfgPath, "graceful:\n  shutdown_timeout: 5s\nlog:\n  level: info\nhosts: [c]\n")
+	require.NoError(t, f.Reload())
+	assert.Equal(t, []string{"c"}, *hosts)
+	assert.Equal(t, 5*time.Second, *timeout)
+	assert.Equal(t, 5*time.Second, dynTimeout.Load())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&gracefulCalls))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&logCalls))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&allCalls))
+
+	// NOTE: invalid file keeps the current values.
+	writeConfig(t, cfgPath, "graceful: [")
+	require.Error(t, f.Reload())
+	assert.Equal(t, 5*time.Second, dynTimeout.Load())
+
+	// NOTE: the same values do not notify the subscribers.
+	writeConfig(t, cfgPath, "graceful:\n  shutdown_timeout: 5s\nlog:\n  level: info\nhosts: [c]\n")
+	require.NoError(t, f.Reload())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&allCalls))
+}
+
+func TestReload_OnlyWatched(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, cfgPath, "log:\n  level: info\nworkers: 2\nname: initial\n")
+
+	f := NewFlagSet("reload_watched", pflag.ContinueOnError)
+	level := f.String("log.level", "debug", "")
+	workers := NewDynamicIn(f, f.Int("workers", 1, ""))
+	name := f.String("name", "", "")
+	f.OnChange("log", func() {})
+	require.NoError(t, f.Init(&cfgPath))
+
+	// NOTE: the param nobody watches keeps its value, so its pointer can be read concurrently.
+	writeConfig(t, cfgPath, "log:\n  level: warn\nworkers: 4\nname: changed\n")
+	require.NoError(t, f.Reload())
+	assert.Equal(t, "warn", *level)
+	assert.Equal(t, 4, workers.Load())
+	assert.Equal(t, "initial", *name)
+}
+
+func TestReload_Validation(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, cfgPath, "client:\n  max_conns: 10\n  hosts: [a]\n")
+
+	type clientConfig struct {
+		MaxConns int      `config:"max_conns" validate:"min=1"`
+		Hosts    []string `config:"hosts" validate:"required"`
+	}
+
+	f := NewFlagSet("reload_validation", pflag.ContinueOnError)
+	cfg := &clientConfig{}
+	require.NoError(t, f.Bind("client", cfg))
+	maxConns := NewDynamicIn(f, &cfg.MaxConns)
+
+	var calls int
+	f.OnChange("client", func() {
+		calls++
+	})
+	require.NoError(t, f.Init(&cfgPath))
+
+	// NOTE: invalid values are not applied, including the valid ones of the same reload.
+	writeConfig(t, cfgPath, "client:\n  max_conns: 0\n  hosts: [b, c]\n")
+	err := f.Reload()
+	var verr ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, 10, maxConns.Load())
+	assert.Equal(t, []string{"a"}, cfg.Hosts)
+	assert.Equal(t, 0, calls)
+
+	writeConfig(t, cfgPath, "client:\n  max_conns: 20\n  hosts: [b, c]\n")
+	require.NoError(t, f.Reload())
+	assert.Equal(t, 20, maxConns.Load())
+	assert.Equal(t, []string{"b", "c"}, cfg.Hosts)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWatch(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []WatchOption
+	}{
+		{
+			name: "Notify",
+		},
+		{
+			name: "Poll",
+			opts: []WatchOption{WithPollInterval(10 * time.Millisecond)},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+			writeConfig(t, cfgPath, "retries:\n  max_attempts: 3\n")
+
+			f := NewFlagSet("watch"+tt.name, pflag.ContinueOnError)
+			attempts := NewDynamicIn(f, f.Int("retries.max_attempts", 1, ""))
+			changed := make(chan struct{}, 1)
+			f.OnChange("retries", func() {
+				changed <- struct{}{}
+			})
+
+			require.NoError(t, f.Init(&cfgPath))
+			assert.Equal(t, 3, attempts.Load())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			require.NoError(t, f.Watch(ctx, tt.opts...))
+
+			// NOTE: modification time resolution of some filesystems is too coarse for polling.
+			time.Sleep(20 * time.Millisecond)
+			writeConfig(t, cfgPath, "retries:\n  max_attempts: 10\n")
+
+			select {
+			case <-changed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("config change is not detected")
+			}
+			assert.Equal(t, 10, attempts.Load())
+		})
+	}
+}
+
+func TestWatch_NoConfigFile(t *testing.T) {
+	f := NewFlagSet("watch_no_file", pflag.ContinueOnError)
+	require.NoError(t, f.Init(nil))
+
+	assert.Equal(t, ErrNoConfigFile, f.Watch(context.Background()))
+	assert.Equal(t, ErrNoConfigFile, f.Reload())
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	// RedactedValue replaces values of secret params when they are printed.
+	RedactedValue = "******"
+
+	annotationSecret = "secret"
+	schemeSeparator  = "://"
+)
+
+// SecretProvider resolves references to secrets.
+//
+// ref is a part of the reference after the scheme, for example,
+// "secret/data/db#password" for the reference "vault://secret/data/db#password".
+type SecretProvider interface {
+	Resolve(ref string) (string, error)
+}
+
+// SecretProviderFunc is an adapter to allow the use of ordinary functions as SecretProvider.
+type SecretProviderFunc func(ref string) (string, error)
+
+// Resolve calls fn(ref).
+func (fn SecretProviderFunc) Resolve(ref string) (string, error) {
+	return fn(ref)
+}
+
+var (
+	providersMu     sync.RWMutex
+	secretProviders = map[string]SecretProvider{
+		"file": SecretProviderFunc(resolveFile),
+		"env":  SecretProviderFunc(resolveEnv),
+	}
+)
+
+// RegisterSecretProvider registers the provider of secrets referenced as "scheme://ref".
+//
+// Providers "file" and "env" are registered by default and can be overridden.
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	providersMu.Lock()
+	secretProviders[scheme] = p
+	providersMu.Unlock()
+}
+
+func lookupSecretProvider(value string) (SecretProvider, string, bool) {
+	i := strings.Index(value, schemeSeparator)
+	if i <= 0 {
+		return nil, "", false
+	}
+
+	providersMu.RLock()
+	p, ok := secretProviders[value[:i]]
+	providersMu.RUnlock()
+
+	return p, value[i+len(schemeSeparator):], ok
+}
+
+// resolveFile reads the secret from the file, trailing newline is trimmed.
+func resolveFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func resolveEnv(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return v, nil
+}
+
+// Secret defines a string flag holding a secret with specified name, default value, and usage string.
+// The return value is the address of a string variable that stores the value of the flag.
+//
+// Values of secret flags are redacted when the flags are printed.
+func Secret(name, defValue, description string) *string {
+	return defaultFlagSet.Secret(name, defValue, description)
+}
+
+// Secret defines a string flag holding a secret with specified name, default value, and usage string.
+// The return value is the address of a string variable that stores the value of the flag.
+//
+// Values of secret flags are redacted when the flags are printed.
+func (f *FlagSet) Secret(name, defValue, description string) *string {
+	p := f.FlagSet.String(name, defValue, description)
+	_ = f.MarkSecret(name)
+	return p
+}
+
+// MarkSecret marks the global configuration param as holding a secret.
+func MarkSecret(name string) error {
+	return defaultFlagSet.MarkSecret(name)
+}
+
+// MarkSecret marks the param as holding a secret.
+func (f *FlagSet) MarkSecret(name string) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+
+	markSecret(flag)
+	return nil
+}
+
+// IsSecret reports whether the global configuration param holds a secret.
+func IsSecret(name string) bool {
+	return defaultFlagSet.IsSecret(name)
+}
+
+// IsSecret reports whether the param holds a secret.
+func (f *FlagSet) IsSecret(name string) bool {
+	flag := f.Lookup(name)
+	return flag != nil && isSecret(flag)
+}
+
+func markSecret(flag *pflag.Flag) {
+	if isSecret(flag) {
+		return
+	}
+
+	if flag.Annotations == nil {
+		flag.Annotations = map[string][]string{}
+	}
+	flag.Annotations[annotationSecret] = []string{"true"}
+
+	// NOTE: default value is printed in the usage message.
+	if flag.DefValue != "" {
+		if _, _, ok := lookupSecretProvider(flag.DefValue); !ok {
+			flag.DefValue = RedactedValue
+		}
+	}
+}
+
+func isSecret(flag *pflag.Flag) bool {
+	_, ok := flag.Annotations[annotationSecret]
+	return ok
+}
+
+// resolveValue resolves the value of the secret flag if it references a secret.
+//
+// Values of other flags are never resolved, so a plain string which looks
+// like a reference does not read files or environment variables.
+func resolveValue(flag *pflag.Flag, value string) (string, bool, error) {
+	if !isSecret(flag) || flag.Value.Type() != "string" {
+		return value, false, nil
+	}
+
+	p, ref, ok := lookupSecretProvider(value)
+	if !ok {
+		return value, false, nil
+	}
+
+	secret, err := p.Resolve(ref)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to resolve secret of %s: %w", flag.Name, err)
+	}
+
+	return secret, true, nil
+}
+
+// resolveSecrets replaces references to secrets with the resolved values.
+func (f *FlagSet) resolveSecrets() error {
+	var errs []string
+	f.VisitAll(func(flag *pflag.Flag) {
+		value, ok, err := resolveValue(flag, flag.Value.String())
+		if err != nil {
+			errs = append(errs, err.Error())
+			return
+		}
+		if !ok {
+			return
+		}
+
+		_ = flag.Value.Set(value)
+	})
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "db_pass")
+	require.NoError(t, os.WriteFile(secretPath, []byte("s3cr3t\n"), 0o600))
+	t.Setenv("GOBUNS_TEST_RABBIT_PASS", "guest")
+
+	RegisterSecretProvider("vault", SecretProviderFunc(func(ref string) (string, error) {
+		if ref != "secret/data/redis#password" {
+			return "", errors.New("secret not found")
+		}
+		return "vault-pass", nil
+	}))
+
+	cfgPath := filepath.Join(dir, "config.yaml")
+	writeConfig(t, cfgPath, strings.Join([]string{
+		"mysql:",
+		"  password: file://" + secretPath,
+		"rabbit:",
+		"  password: env://GOBUNS_TEST_RABBIT_PASS",
+		"redis:",
+		"  password: vault://secret/data/redis#password",
+		"api:",
+		"  url: https://example.com",
+		"  callback: env://GOBUNS_TEST_RABBIT_PASS",
+		"",
+	}, "\n"))
+
+	f := NewFlagSet("secrets", pflag.ContinueOnError)
+	mysqlPass := f.Secret("mysql.password", "", "")
+	rabbitPass := f.Secret("rabbit.password", "", "")
+	redisPass := f.String("redis.password", "", "")
+	require.NoError(t, f.MarkSecret("redis.password"))
+	tntPass := f.Secret("tnt.password", "tnt-pass", "")
+	apiURL := f.String("api.url", "", "")
+	apiCallback := f.String("api.callback", "", "")
+
+	require.NoError(t, f.Init(&cfgPath))
+	assert.Equal(t, "s3cr3t", *mysqlPass)
+	assert.Equal(t, "guest", *rabbitPass)
+	assert.Equal(t, "vault-pass", *redisPass)
+	assert.Equal(t, "tnt-pass", *tntPass)
+	assert.Equal(t, "https://example.com", *apiURL)
+	// NOTE: references are resolved only for secret params.
+	assert.Equal(t, "env://GOBUNS_TEST_RABBIT_PASS", *apiCallback)
+
+	assert.True(t, f.IsSecret("mysql.password"))
+	assert.True(t, f.IsSecret("rabbit.password"))
+	assert.True(t, f.IsSecret("redis.password"))
+	assert.True(t, f.IsSecret("tnt.password"))
+	assert.False(t, f.IsSecret("api.url"))
+	assert.False(t, f.IsSecret("api.callback"))
+
+	usages := f.FlagUsages()
+	assert.NotContains(t, usages, "tnt-pass")
+	assert.Contains(t, usages, RedactedValue)
+
+	// NOTE: rotated secret is resolved again on reload.
+	dynPass := NewDynamicIn(f, mysqlPass)
+	require.NoError(t, os.WriteFile(secretPath, []byte("rotated\n"), 0o600))
+	require.NoError(t, f.Reload())
+	assert.Equal(t, "rotated", dynPass.Load())
+}
+
+func TestSecrets_ResolveError(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, cfgPath, "mysql:\n  password: env://GOBUNS_TEST_UNDEFINED_VAR\n")
+
+	f := NewFlagSet("secrets_error", pflag.ContinueOnError)
+	f.Secret("mysql.password", "", "")
+
+	err := f.Init(&cfgPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mysql.password")
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"sort"
+	"sync"
+)
+
+// Map is a dynamic configuration section.
+//
+// Each child key of the section prefix is a named item, e.g. one of redis clusters
+// or kafka topics. Params of the items are registered by the config constructor when
+// the key is found in the configuration, so the amount of items is not fixed at compile time.
+type Map[T any] struct {
+	f       *FlagSet
+	name    string
+	prefix  string
+	factory func(prefix string) func() (T, error)
+
+	mu       sync.Mutex
+	builders map[string]func() (T, error)
+}
+
+// Section declares the dynamic configuration section having the given prefix.
+//
+// factory is a config constructor called for each child key with the prefix of the item,
+// e.g. retry.GetRetryConfig or external.NewConfig. It is called under the lock of the FlagSet,
+// so it must only define params and must not call OnChange or NewDynamicIn.
+func Section[T any](prefix string, factory func(prefix string, set ...*FlagSet) func() T, set ...*FlagSet) *Map[T] {
+	f := FlagSetOrDefault(set...)
+
+	return newMap(f, prefix, func(p string) func() (T, error) {
+		fn := factory(p, f)
+		return func() (T, error) {
+			return fn(), nil
+		}
+	})
+}
+
+// SectionErr declares the dynamic configuration section which items are built
+// by a config constructor returning an error, e.g. redisconfig.NewClusterConfigWithPrefix.
+func SectionErr[T any](prefix string, factory func(prefix string, set ...*FlagSet) func() (T, error), set ...*FlagSet) *Map[T] {
+	f := FlagSetOrDefault(set...)
+
+	return newMap(f, prefix, func(p string) func() (T, error) {
+		return factory(p, f)
+	})
+}
+
+func newMap[T any](f *FlagSet, prefix string, factory func(prefix string) func() (T, error)) *Map[T] {
+	m := &Map[T]{
+		f:        f,
+		name:     prefix,
+		prefix:   SanitizePrefix(prefix),
+		factory:  factory,
+		builders: make(map[string]func() (T, error)),
+	}
+
+	// NOTE: the items are read by Load under the lock, so their params are reloaded.
+	f.mu.Lock()
+	f.sections = append(f.sections, m.prefix)
+	f.mu.Unlock()
+
+	return m
+}
+
+// Keys returns the sorted child keys of the section found in the configuration files.
+func (m *Map[T]) Keys() []string {
+	keys := m.f.SubConfigSuffixes(m.name)
+	sort.Strings(keys)
+	return keys
+}
+
+// Load returns the items of the section by their keys.
+//
+// It must be called after the configuration is initialized. Params of the keys which
+// appeared after reloading the configuration are registered on the next call.
+func (m *Map[T]) Load() (map[string]T, error) {
+	keys, changed, err := m.define()
+	m.f.notify(changed)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// NOTE: the builders read the params, which are changed on reload.
+	m.f.mu.RLock()
+	defer m.f.mu.RUnlock()
+
+	items := make(map[string]T, len(keys))
+	for _, key := range keys {
+		v, err := m.builders[key]()
+		if err != nil {
+			return nil, err
+		}
+		items[key] = v
+	}
+
+	return items, nil
+}
+
+// define registers params of the new keys and sets their values from the configuration.
+//
+// It returns the keys of the section and names of the changed params.
+func (m *Map[T]) define() ([]string, []string, error) {
+	m.f.reloadMu.Lock()
+	defer m.f.reloadMu.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := m.Keys()
+	defined := false
+
+	// NOTE: params are defined under the lock, so they are not visited
+	// concurrently by ReInit or Entries.
+	m.f.mu.Lock()
+	for _, key := range keys {
+		if _, ok := m.builders[key]; ok {
+			continue
+		}
+
+		m.builders[key] = m.factory(m.prefix + key)
+		defined = true
+	}
+	m.f.mu.Unlock()
+
+	if !defined {
+		return keys, nil, nil
+	}
+
+	changed, err := m.f.apply(m.prefix)
+	return keys, changed, err
+}
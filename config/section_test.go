@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type topicConfig struct {
+	Partitions int
+	Retention  time.Duration
+}
+
+func newTopicConfig(prefix string, set ...*FlagSet) func() *topicConfig {
+	fs := FlagSetOrDefault(set...)
+
+	prefix = SanitizePrefix(prefix)
+	var (
+		partitions = fs.Int(prefix+"partitions", 1, "topic partitions")
+		retention  = fs.Duration(prefix+"retention", time.Hour, "topic retention")
+	)
+
+	return func() *topicConfig {
+		return &topicConfig{
+			Partitions: *partitions,
+			Retention:  *retention,
+		}
+	}
+}
+
+func TestSection(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, cfgPath, "kafka:\n  topics:\n    orders:\n      partitions: 8\n    payments:\n      retention: 24h\n")
+
+	f := NewFlagSet("section", pflag.ContinueOnError)
+	topics := Section("kafka.topics", newTopicConfig, f)
+
+	var calls int
+	f.OnChange("kafka.topics", func() {
+		calls++
+	})
+
+	require.NoError(t, f.Init(&cfgPath))
+	assert.Equal(t, []string{"orders", "payments"}, topics.Keys())
+
+	items, err := topics.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*topicConfig{
+		"orders":   {Partitions: 8, Retention: time.Hour},
+		"payments": {Partitions: 1, Retention: 24 * time.Hour},
+	}, items)
+	assert.NotNil(t, f.Lookup("kafka.topics.orders.partitions"))
+
+	// NOTE: params of the defined keys are not registered twice.
+	_, err = topics.Load()
+	require.NoError(t, err)
+
+	writeConfig(t, cfgPath, "kafka:\n  topics:\n    orders:\n      partitions: 16\n    refunds:\n      partitions: 2\n")
+	require.NoError(t, f.Reload())
+
+	items, err = topics.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*topicConfig{
+		"orders":  {Partitions: 16, Retention: time.Hour},
+		"refunds": {Partitions: 2, Retention: time.Hour},
+	}, items)
+	// NOTE: subscribers are notified on the first load, on reload
+	// and on the load defining the new key.
+	assert.Equal(t, 3, calls)
+}
+
+func TestSectionErr(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, cfgPath, "clusters:\n  main:\n    addr: localhost:6379\n  backup:\n    addr: \"\"\n")
+
+	errNoAddr := errors.New("address is not set")
+	factory := func(prefix string, set ...*FlagSet) func() (string, error) {
+		addr := FlagSetOrDefault(set...).String(prefix+".addr", "", "")
+		return func() (string, error) {
+			if *addr == "" {
+				return "", errNoAddr
+			}
+			return *addr, nil
+		}
+	}
+
+	f := NewFlagSet("section_err", pflag.ContinueOnError)
+	clusters := SectionErr("clusters", factory, f)
+	require.NoError(t, f.Init(&cfgPath))
+
+	_, err := clusters.Load()
+	assert.Equal(t, errNoAddr, err)
+}
+
+func TestSectionConcurrentReload(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, cfgPath, "kafka:\n  topics:\n    orders:\n      partitions: 8\n")
+
+	f := NewFlagSet("section_concurrent", pflag.ContinueOnError)
+	topics := Section("kafka.topics", newTopicConfig, f)
+	require.NoError(t, f.Init(&cfgPath))
+
+	// NOTE: the test is meaningful with the race detector.
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			writeConfig(t, cfgPath, fmt.Sprintf("kafka:\n  topics:\n    orders:\n      partitions: %d\n    topic%d:\n      partitions: 2\n", i, i))
+			assert.NoError(t, f.Reload())
+			f.ReInit("kafka")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_, err := topics.Load()
+			assert.NoError(t, err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_ = f.Entries()
+		}
+	}()
+	wg.Wait()
+
+	items, err := topics.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 19, items["orders"].Partitions)
+}
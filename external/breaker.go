@@ -0,0 +1,84 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/city-mobil/gobuns/barber"
+	"github.com/city-mobil/gobuns/promlib"
+)
+
+// ErrCircuitOpen is matched by CircuitOpenError with errors.Is.
+var ErrCircuitOpen = barber.ErrCircuitOpen
+
+// CircuitOpenError is returned when the circuit-breaker of the upstream
+// is open and the request is not sent.
+type CircuitOpenError struct {
+	// Key is the circuit-breaker key of the upstream.
+	Key string
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for upstream %s", e.Key)
+}
+
+// Is reports whether the target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// HostKey returns the host of the request URL.
+//
+// It is the default circuit-breaker key of the upstream.
+func HostKey(r *http.Request) string {
+	return r.URL.Host
+}
+
+// newBreaker creates the circuit-breaker of the upstreams if it is enabled.
+//
+// The returned function unregisters the metrics of the circuit-breaker.
+func newBreaker(cfg *Config) (barber.KeyedBarber, func(), error) {
+	if cfg.CircuitBreaker == nil {
+		return nil, func() {}, nil
+	}
+
+	cb := barber.NewKeyedBarber(cfg.CircuitBreaker)
+	if !cfg.Metrics.Collect {
+		return cb, func() {}, nil
+	}
+
+	reg := promlib.Registerer(cfg.Metrics.Options...)
+	collector := barber.NewKeyedCollector(cfg.Name, cb)
+	if err := reg.Register(collector); err != nil {
+		return nil, nil, err
+	}
+
+	return cb, func() {
+		reg.Unregister(collector)
+	}, nil
+}
+
+// isAvailable reports whether the request to the upstream can be sent.
+func (c *client) isAvailable(key string) bool {
+	return c.breaker == nil || c.breaker.Allow(key, time.Now())
+}
+
+// reportResult reports the result of the request to the circuit-breaker.
+//
+// Transport errors and 5xx responses are the upstream failures.
+// Canceled requests are not reported.
+func (c *client) reportResult(key string, resp *http.Response, err error) {
+	if c.breaker == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if barber.DefaultFailurePredicate(err) || (err == nil && resp.StatusCode >= http.StatusInternalServerError) {
+		c.breaker.AddError(key, time.Now())
+		return
+	}
+
+	c.breaker.AddSuccess(key, time.Now())
+}
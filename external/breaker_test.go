@@ -0,0 +1,94 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/city-mobil/gobuns/barber"
+	"github.com/city-mobil/gobuns/promlib"
+)
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	failingSrv, failing := newTestServer(t, alwaysFail, http.StatusInternalServerError, "")
+	healthySrv, healthy := newTestServer(t, 0, 0, "")
+
+	cl := newTestClient(t, 1, &Config{
+		CircuitBreaker: &barber.Config{
+			Threshold:   10,
+			MaxFails:    2,
+			OpenTimeout: time.Minute,
+		},
+	})
+
+	for i := 0; i < 3; i++ {
+		status, err := doGet(context.Background(), t, cl, failingSrv.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, status)
+	}
+
+	_, err := doGet(context.Background(), t, cl, failingSrv.URL)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+
+	var openErr *CircuitOpenError
+	require.True(t, errors.As(err, &openErr))
+	assert.Equal(t, failingSrv.Listener.Addr().String(), openErr.Key)
+	assert.Equal(t, int32(3), atomic.LoadInt32(failing))
+
+	// NOTE: other upstreams are not affected.
+	status, err := doGet(context.Background(), t, cl, healthySrv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(healthy))
+}
+
+func TestClient_CircuitBreakerKey(t *testing.T) {
+	srv, _ := newTestServer(t, alwaysFail, http.StatusBadGateway, "")
+
+	cl := newTestClient(t, 5, &Config{
+		CircuitBreaker: &barber.Config{
+			Threshold: 10,
+			MaxFails:  1,
+		},
+		CircuitBreakerKey: func(r *http.Request) string {
+			return r.URL.Path
+		},
+	})
+
+	// NOTE: the breaker opened during the retries stops them.
+	_, err := doGet(context.Background(), t, cl, srv.URL+"/orders")
+
+	var openErr *CircuitOpenError
+	require.True(t, errors.As(err, &openErr))
+	assert.Equal(t, "/orders", openErr.Key)
+}
+
+func TestClient_CircuitBreakerMetrics(t *testing.T) {
+	srv, _ := newTestServer(t, 0, 0, "")
+
+	reg := prometheus.NewRegistry()
+	cl := newTestClient(t, 1, &Config{
+		Name:           "breaker_metrics",
+		CircuitBreaker: &barber.Config{},
+		Metrics: ConfigMetrics{
+			Collect: true,
+			Options: []promlib.InstrumentOption{promlib.InstrumentWithRegisterer(reg)},
+		},
+	})
+
+	_, err := doGet(context.Background(), t, cl, srv.URL)
+	require.NoError(t, err)
+
+	// NOTE: the breaker metrics are registered in the given registry.
+	count, err := testutil.GatherAndCount(reg, "circuit_breaker_state")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,166 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// DefaultMaxBodySize is a default maximum size of the response body read by Call.
+	DefaultMaxBodySize = 10 << 20
+
+	// maxErrorBodySize is a maximum size of the response body kept in HTTPError.
+	maxErrorBodySize = 4 << 10
+)
+
+// ErrBodyTooLarge is returned by Call when the response body exceeds the maximum size.
+var ErrBodyTooLarge = errors.New("response body is too large")
+
+// HTTPError is returned by Call for responses with non-2xx status codes.
+type HTTPError struct {
+	// StatusCode is the response status code.
+	StatusCode int
+
+	// Status is the response status, e.g. "503 Service Unavailable".
+	Status string
+
+	// Header is the response header.
+	Header http.Header
+
+	// Body is the beginning of the response body, at most 4KB.
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("unexpected response status: %s", e.Status)
+	}
+	return fmt.Sprintf("unexpected response status: %s: %s", e.Status, e.Body)
+}
+
+// CallOption is an optional parameter of Call.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	codec       Codec
+	header      http.Header
+	maxBodySize int64
+}
+
+// WithCodec sets the codec of the request and response bodies.
+//
+// By default: JSON.
+func WithCodec(codec Codec) CallOption {
+	return func(o *callOptions) {
+		o.codec = codec
+	}
+}
+
+// WithHeader adds the header to the request.
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		o.header.Add(key, value)
+	}
+}
+
+// WithMaxBodySize sets the maximum size of the response body.
+//
+// By default: DefaultMaxBodySize.
+func WithMaxBodySize(size int64) CallOption {
+	return func(o *callOptions) {
+		o.maxBodySize = size
+	}
+}
+
+// GetJSON performs GET request to the url and decodes the response body into out.
+func GetJSON(ctx context.Context, c Client, url string, out interface{}, opts ...CallOption) error {
+	return Call(ctx, c, http.MethodGet, url, nil, out, opts...)
+}
+
+// PostJSON performs POST request to the url with encoded in as body
+// and decodes the response body into out.
+func PostJSON(ctx context.Context, c Client, url string, in, out interface{}, opts ...CallOption) error {
+	return Call(ctx, c, http.MethodPost, url, in, out, opts...)
+}
+
+// Call performs the request with encoded in as body and decodes the response body into out
+// using the codec, JSON by default.
+//
+// Nil in sends the request without body, nil out discards the response body.
+// For non-2xx responses *HTTPError is returned. The response body is always closed.
+func Call(ctx context.Context, c Client, method, url string, in, out interface{}, opts ...CallOption) error {
+	o := &callOptions{
+		codec:       JSON,
+		header:      make(http.Header),
+		maxBodySize: DefaultMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := o.codec.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request body: %w", err)
+		}
+		// NOTE: bytes.Reader allows the client to replay the body on retries.
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range o.header {
+		req.Header[k] = v
+	}
+	if in != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", o.codec.ContentType())
+	}
+	if out != nil && req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", o.codec.ContentType())
+	}
+
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return &HTTPError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     resp.Header,
+			Body:       data,
+		}
+	}
+
+	if out == nil {
+		drainBody(resp.Body)
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, o.maxBodySize+1))
+	if err != nil {
+		return fmt.Errorf("read response body: %w", err)
+	}
+	if int64(len(data)) > o.maxBodySize {
+		return ErrBodyTooLarge
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	if err := o.codec.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("unmarshal response body: %w", err)
+	}
+
+	return nil
+}
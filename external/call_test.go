@@ -0,0 +1,150 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type order struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+// msgpString is a string implementing msgp interfaces.
+type msgpString string
+
+func (s msgpString) MarshalMsg(b []byte) ([]byte, error) {
+	return msgp.AppendString(b, string(s)), nil
+}
+
+func (s *msgpString) UnmarshalMsg(b []byte) ([]byte, error) {
+	v, rest, err := msgp.ReadStringBytes(b)
+	*s = msgpString(v)
+	return rest, err
+}
+
+func TestCall_JSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		assert.Equal(t, "token", r.Header.Get("X-Auth"))
+
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"id":1,"status":"new"}`))
+		case http.MethodPost:
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+			var in order
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+			in.Status = "created"
+			_ = json.NewEncoder(w).Encode(in)
+		}
+	}))
+	defer srv.Close()
+
+	cl := newTestClient(t, 1, &Config{})
+
+	var out order
+	err := GetJSON(context.Background(), cl, srv.URL, &out, WithHeader("X-Auth", "token"))
+	require.NoError(t, err)
+	assert.Equal(t, order{ID: 1, Status: "new"}, out)
+
+	err = PostJSON(context.Background(), cl, srv.URL, &order{ID: 2}, &out, WithHeader("X-Auth", "token"))
+	require.NoError(t, err)
+	assert.Equal(t, order{ID: 2, Status: "created"}, out)
+}
+
+func TestCall_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Request-Id", "42")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(strings.Repeat("a", 2*maxErrorBodySize)))
+	}))
+	defer srv.Close()
+
+	var out order
+	err := GetJSON(context.Background(), newTestClient(t, 1, &Config{}), srv.URL, &out)
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	assert.Equal(t, "404 Not Found", httpErr.Status)
+	assert.Equal(t, "42", httpErr.Header.Get("X-Request-Id"))
+	assert.Len(t, httpErr.Body, maxErrorBodySize)
+}
+
+func TestCall_MaxBodySize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":1,"status":"new"}`))
+	}))
+	defer srv.Close()
+
+	var out order
+	err := GetJSON(context.Background(), newTestClient(t, 1, &Config{}), srv.URL, &out, WithMaxBodySize(10))
+	assert.Equal(t, ErrBodyTooLarge, err)
+}
+
+func TestCall_Codecs(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		in    interface{}
+		out   interface{}
+		want  interface{}
+	}{
+		{
+			name:  "Protobuf",
+			codec: Protobuf,
+			in:    wrapperspb.String("ping"),
+			out:   &wrapperspb.StringValue{},
+			want:  wrapperspb.String("ping"),
+		},
+		{
+			name:  "Msgpack",
+			codec: Msgpack,
+			in:    msgpString("ping"),
+			out:   new(msgpString),
+			want:  func() *msgpString { s := msgpString("ping"); return &s }(),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.codec.ContentType(), r.Header.Get("Content-Type"))
+				w.Header().Set("Content-Type", tt.codec.ContentType())
+				_, _ = io.Copy(w, r.Body)
+			}))
+			defer srv.Close()
+
+			err := Call(context.Background(), newTestClient(t, 1, &Config{}), http.MethodPut, srv.URL, tt.in, tt.out, WithCodec(tt.codec))
+			require.NoError(t, err)
+			if m, ok := tt.want.(proto.Message); ok {
+				assert.True(t, proto.Equal(m, tt.out.(proto.Message)))
+			} else {
+				assert.Equal(t, tt.want, tt.out)
+			}
+		})
+	}
+}
+
+func TestCodec_InvalidType(t *testing.T) {
+	_, err := Protobuf.Marshal(order{})
+	assert.Error(t, err)
+
+	_, err = Msgpack.Marshal(order{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tinylib/msgp/msgp"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes request bodies and decodes response bodies of Call.
+type Codec interface {
+	// ContentType returns the media type sent in Content-Type and Accept headers.
+	ContentType() string
+
+	// Marshal encodes v.
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal decodes data into v.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSON is a codec using encoding/json.
+	JSON Codec = jsonCodec{}
+
+	// Protobuf is a codec for values implementing proto.Message.
+	Protobuf Codec = protobufCodec{}
+
+	// Msgpack is a codec for values implementing msgp.Marshaler and msgp.Unmarshaler,
+	// e.g. generated by github.com/tinylib/msgp.
+	Msgpack Codec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf codec: %T is not proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf codec: %T is not proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(msgp.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("msgpack codec: %T is not msgp.Marshaler", v)
+	}
+	return m.MarshalMsg(nil)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(msgp.Unmarshaler)
+	if !ok {
+		return fmt.Errorf("msgpack codec: %T is not msgp.Unmarshaler", v)
+	}
+	_, err := m.UnmarshalMsg(data)
+	return err
+}
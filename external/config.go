@@ -5,8 +5,10 @@ import (
 	"crypto/tls"
 	"log"
 	"net"
+	"net/http"
 	"time"
 
+	"github.com/city-mobil/gobuns/barber"
 	"github.com/city-mobil/gobuns/config"
 	"github.com/city-mobil/gobuns/promlib"
 	"github.com/city-mobil/gobuns/retry"
@@ -27,6 +29,7 @@ const (
 	defRequestTimeout    = 500 * time.Millisecond
 	defKeepAliveInterval = 30 * time.Second
 	defMaxIdleConns      = 100
+	defMaxRetryAfter     = time.Second
 	defNoHTTPS           = true
 	defVersionTLS        = VersionTLS12
 )
@@ -79,6 +82,54 @@ type Config struct {
 
 	OnRetry func(n uint, err error)
 
+	// Classifier decides whether a request error can be retried.
+	//
+	// Responses of RetryMethods with 4xx and 5xx status codes are passed to
+	// the classifier as *StatusError.
+	//
+	// By default: NewClassifier(RetryStatusCodes...), timed out requests
+	// and responses with RetryStatusCodes are retried.
+	Classifier retry.Classifier
+
+	// RetryStatusCodes is a list of response status codes which are retried.
+	// The list replaces DefaultRetryStatusCodes. It is ignored if Classifier is set.
+	//
+	// By default: DefaultRetryStatusCodes.
+	RetryStatusCodes []int
+
+	// RetryMethods is a list of request methods which are retried on response status codes.
+	// Empty non-nil list disables retries on response status codes.
+	//
+	// By default: DefaultRetryMethods, only idempotent requests are retried.
+	RetryMethods []string
+
+	// MaxRetryAfter is a maximum delay requested by the Retry-After response header
+	// which the client waits before the retry. If the server asks to wait longer,
+	// the response is returned as is.
+	//
+	// By default: 1 second.
+	MaxRetryAfter time.Duration
+
+	// CircuitBreaker is a configuration of the circuit-breaker of the upstreams.
+	// Requests to the open upstream fail fast with *CircuitOpenError.
+	//
+	// Nil disables the circuit-breaker.
+	CircuitBreaker *barber.Config
+
+	// CircuitBreakerKey returns the key of the upstream for the request.
+	//
+	// By default: HostKey, the circuit-breaker is kept for each host.
+	CircuitBreakerKey func(r *http.Request) string
+
+	// RateLimit is a configuration of the client-side rate limiter.
+	//
+	// Nil disables the rate limiter.
+	RateLimit *RateLimitConfig
+
+	// Middlewares intercept each request attempt before the tracing
+	// and prometheus middlewares, the first middleware is the outermost one.
+	Middlewares []Middleware
+
 	// MinVersionTLS contains the minimum TLS version that is acceptable.
 	MinVersionTLS VersionTLS
 
@@ -124,7 +175,9 @@ type ConfigMetrics struct {
 //  // config.InitOnce()
 //  // client := New(cfg())
 //
-func NewConfig(prefix string) func() *Config {
+func NewConfig(prefix string, set ...*config.FlagSet) func() *Config {
+	fs := config.FlagSetOrDefault(set...)
+
 	if prefix != "" {
 		prefix += ".external."
 	} else {
@@ -136,23 +189,51 @@ func NewConfig(prefix string) func() *Config {
 	}
 
 	var (
-		clientName              = config.String(p("client_name"), "", "unique name of the client")
-		dialTimeout             = config.Duration(p("dial_timeout"), defDialTimeout, "dial timeout")
-		keepAlive               = config.Duration(p("keepalive_interval"), defKeepAliveInterval, "keepalive messages interval (protocol specific)")
-		maxIdleConns            = config.Int(p("max_idle_conns"), defMaxIdleConns, "max idle connections to external service")
-		idleTimeout             = config.Duration(p("idle_conn_timeout"), defIdleTimeout, "idle connection timeout")
-		requestTimeout          = config.Duration(p("request_timeout"), defRequestTimeout, "request timeout")
-		noHTTPS                 = config.Bool(p("no_https"), defNoHTTPS, "controls whether a client verifies the server's certificate chain and host name")
-		forceInsecureSkipVerify = config.Bool(p("force_insecure_skip_verify"), false, "force insecure skip verify option")
-		retryCfgFn              = retry.GetRetryConfig(p("retries"))
-		tlsVersion              = config.String(p("tls.version"), "1.2", "TLS version")
-		tlsPublicCert           = config.String(p("tls.cert.public"), "", "path to a public client TLS cert")
-		tlsPrivateCert          = config.String(p("tls.cert.private"), "", "path to a private client TLS cert")
-		tlsRootCert             = config.String(p("tls.cert.root"), "", "path to a root CA cert")
-		metricsCollect          = config.Bool(p("metrics.collect"), false, "enables gathering metrics in Prometheus format")
+		clientName              = fs.String(p("client_name"), "", "unique name of the client")
+		dialTimeout             = fs.Duration(p("dial_timeout"), defDialTimeout, "dial timeout")
+		keepAlive               = fs.Duration(p("keepalive_interval"), defKeepAliveInterval, "keepalive messages interval (protocol specific)")
+		maxIdleConns            = fs.Int(p("max_idle_conns"), defMaxIdleConns, "max idle connections to external service")
+		idleTimeout             = fs.Duration(p("idle_conn_timeout"), defIdleTimeout, "idle connection timeout")
+		requestTimeout          = fs.Duration(p("request_timeout"), defRequestTimeout, "request timeout")
+		noHTTPS                 = fs.Bool(p("no_https"), defNoHTTPS, "controls whether a client verifies the server's certificate chain and host name")
+		forceInsecureSkipVerify = fs.Bool(p("force_insecure_skip_verify"), false, "force insecure skip verify option")
+		retryCfgFn              = retry.GetRetryConfig(p("retries"), fs)
+		retryStatusCodes        = fs.IntSlice(p("retry_status_codes"), nil, "response status codes retried instead of the default ones (429, 502, 503, 504)")
+		retryMethods            = fs.StringSlice(p("retry_methods"), DefaultRetryMethods, "request methods retried on response status codes, empty list disables the retries")
+		maxRetryAfter           = fs.Duration(p("max_retry_after"), defMaxRetryAfter, "maximum delay requested by the Retry-After header to wait before the retry")
+		tlsVersion              = fs.String(p("tls.version"), "1.2", "TLS version")
+		tlsPublicCert           = fs.String(p("tls.cert.public"), "", "path to a public client TLS cert")
+		tlsPrivateCert          = fs.String(p("tls.cert.private"), "", "path to a private client TLS cert")
+		tlsRootCert             = fs.String(p("tls.cert.root"), "", "path to a root CA cert")
+		metricsCollect          = fs.Bool(p("metrics.collect"), false, "enables gathering metrics in Prometheus format")
+		breakerEnabled          = fs.Bool(p("circuit_breaker.enabled"), false, "enables the circuit-breaker of the upstreams")
+		breakerCfgFn            = barber.NewConfig(p("circuit_breaker"), fs)
+		rateLimitRPS            = fs.Float64(p("rate_limit.rps"), 0, "requests allowed per second, 0 disables the rate limiter")
+		rateLimitBurst          = fs.Int(p("rate_limit.burst"), 0, "maximum requests allowed at once, rps rounded up is used if not set")
+		rateLimitPerHost        = fs.Bool(p("rate_limit.per_host"), false, "enables separate rate limits for each host")
+		rateLimitWait           = fs.Bool(p("rate_limit.wait"), false, "makes requests wait for the rate limiter instead of failing fast")
+		rateLimitMaxWait        = fs.Duration(p("rate_limit.max_wait"), 0, "maximum time to wait for the rate limiter, 0 means the request context only")
+		rateLimitIdleTimeout    = fs.Duration(p("rate_limit.idle_timeout"), defRateLimitIdleTimeout, "period of time after which the rate limit of unused host is evicted")
 	)
 
 	return func() *Config {
+		var breakerCfg *barber.Config
+		if *breakerEnabled {
+			breakerCfg = breakerCfgFn()
+		}
+
+		var rateLimitCfg *RateLimitConfig
+		if *rateLimitRPS > 0 {
+			rateLimitCfg = &RateLimitConfig{
+				RPS:         *rateLimitRPS,
+				Burst:       *rateLimitBurst,
+				PerHost:     *rateLimitPerHost,
+				Wait:        *rateLimitWait,
+				MaxWait:     *rateLimitMaxWait,
+				IdleTimeout: *rateLimitIdleTimeout,
+			}
+		}
+
 		return &Config{
 			Name:                    *clientName,
 			DialContext:             makeDialContext(*dialTimeout, *keepAlive),
@@ -161,11 +242,16 @@ func NewConfig(prefix string) func() *Config {
 			RequestTimeout:          *requestTimeout,
 			NoHTTPS:                 *noHTTPS,
 			RetryConfig:             retryCfgFn(),
+			RetryStatusCodes:        *retryStatusCodes,
+			RetryMethods:            *retryMethods,
+			MaxRetryAfter:           *maxRetryAfter,
 			MinVersionTLS:           VersionTLS(*tlsVersion),
 			PrivateCertPath:         *tlsPrivateCert,
 			ForceInsecureSkipVerify: *forceInsecureSkipVerify,
 			PublicCertPath:          *tlsPublicCert,
 			CACertPath:              *tlsRootCert,
+			CircuitBreaker:          breakerCfg,
+			RateLimit:               rateLimitCfg,
 			Metrics: ConfigMetrics{
 				Collect: *metricsCollect,
 			},
@@ -196,6 +282,18 @@ func (cfg *Config) withDefaults() (c Config) {
 	if c.OnRetry == nil {
 		c.OnRetry = defOnRetry
 	}
+	if c.Classifier == nil {
+		c.Classifier = NewClassifier(c.RetryStatusCodes...)
+	}
+	if c.RetryMethods == nil {
+		c.RetryMethods = DefaultRetryMethods
+	}
+	if c.MaxRetryAfter == 0 {
+		c.MaxRetryAfter = defMaxRetryAfter
+	}
+	if c.CircuitBreakerKey == nil {
+		c.CircuitBreakerKey = HostKey
+	}
 	if c.MinVersionTLS == "" {
 		c.MinVersionTLS = defVersionTLS
 	}
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -14,17 +15,18 @@ import (
 	"github.com/city-mobil/gobuns/retry"
 )
 
+// NOTE: params of the global FlagSet are defined once, so the tests can be run repeatedly.
+var googleCfgFn = NewConfig("http.google")
+
 func TestNewConfig(t *testing.T) {
 	cfgPath, err := filepath.Abs("testdata/config.yml")
 	require.NoError(t, err)
 	os.Args = append(os.Args, "--config="+cfgPath)
 
-	cfgFn := NewConfig("http.google")
-
 	err = config.InitOnce()
 	require.NoError(t, err)
 
-	cfg := cfgFn()
+	cfg := googleCfgFn()
 
 	assert.Equal(t, "ask_google", cfg.Name)
 	assert.True(t, cfg.Metrics.Collect)
@@ -42,6 +44,34 @@ func TestNewConfig(t *testing.T) {
 	assert.False(t, cfg.NoHTTPS)
 }
 
+func TestNewConfig_FlagSet(t *testing.T) {
+	cfgPath, err := filepath.Abs("testdata/config.yml")
+	require.NoError(t, err)
+
+	fs := config.NewFlagSet("external", pflag.ContinueOnError)
+	defFs := config.NewFlagSet("external_default", pflag.ContinueOnError)
+	cfgFn := NewConfig("http.google", fs)
+	defCfgFn := NewConfig("http.yandex", defFs)
+
+	assert.NotNil(t, defFs.Lookup("http.yandex.external.client_name"))
+	require.NotNil(t, fs.Lookup("http.google.external.retries.max_attempts"))
+	assert.Nil(t, fs.Lookup("http.yandex.external.client_name"))
+	assert.Nil(t, config.FlagSetOrDefault().Lookup("http.yandex.external.client_name"))
+
+	require.NoError(t, fs.Init(&cfgPath,
+		"--http.google.external.retries.max_attempts=5",
+		"--http.google.external.retry_status_codes=500,503",
+	))
+
+	cfg := cfgFn()
+	assert.Equal(t, "ask_google", cfg.Name)
+	assert.Equal(t, 5, cfg.RetryConfig.MaxAttempts)
+	assert.Equal(t, []int{500, 503}, cfg.RetryStatusCodes)
+	assert.Empty(t, defCfgFn().RetryStatusCodes)
+	assert.Equal(t, VersionTLS11, cfg.MinVersionTLS)
+	assert.Equal(t, defRequestTimeout, defCfgFn().RequestTimeout)
+}
+
 func TestCastTLSVersion(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -6,15 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/http/httptrace"
+	"strings"
+	"time"
 
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
 
+	"github.com/city-mobil/gobuns/barber"
 	"github.com/city-mobil/gobuns/promlib"
 	"github.com/city-mobil/gobuns/retry"
 )
@@ -33,6 +35,7 @@ type Client interface {
 	//
 	// Be aware that custom transport overrides
 	// all open tracing and prometheus middlewares.
+	// Use Config.Middlewares to intercept requests keeping them.
 	SetCustomTransport(http.RoundTripper)
 
 	// Get performs GET-requests with retries for prepared http.Request
@@ -46,9 +49,15 @@ type Client interface {
 }
 
 type client struct {
-	client  *http.Client
-	retrier *retry.Retrier
-	onRetry func(n uint, err error)
+	client        *http.Client
+	retrier       *retry.Retrier
+	onRetry       func(n uint, err error)
+	classifier    retry.Classifier
+	retryMethods  map[string]struct{}
+	maxRetryAfter time.Duration
+	breaker       barber.KeyedBarber
+	breakerKey    func(r *http.Request) string
+	limiter       *limiter
 }
 
 var (
@@ -79,19 +88,42 @@ func New(userCfg *Config) (Client, error) {
 		return nil, err
 	}
 
+	breaker, unregisterBreaker, err := newBreaker(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter, err := newLimiter(&cfg)
+	if err != nil {
+		// NOTE: metrics are unregistered, so the client can be created again.
+		unregisterBreaker()
+		return nil, err
+	}
+
 	if cfg.Metrics.Collect {
 		tr = promlib.InstrumentRoundTripper(cfg.Name, tr, cfg.Metrics.Options...)
 	}
 
+	retryMethods := make(map[string]struct{}, len(cfg.RetryMethods))
+	for _, method := range cfg.RetryMethods {
+		retryMethods[strings.ToUpper(method)] = struct{}{}
+	}
+
 	return &client{
 		client: &http.Client{
-			Transport: &nethttp.Transport{
+			Transport: chain(&nethttp.Transport{
 				RoundTripper: tr,
-			},
+			}, cfg.Middlewares),
 			Timeout: cfg.RequestTimeout,
 		},
-		retrier: retry.New(cfg.RetryConfig),
-		onRetry: cfg.OnRetry,
+		retrier:       retry.New(cfg.RetryConfig),
+		onRetry:       cfg.OnRetry,
+		classifier:    cfg.Classifier,
+		retryMethods:  retryMethods,
+		maxRetryAfter: cfg.MaxRetryAfter,
+		breaker:       breaker,
+		breakerKey:    cfg.CircuitBreakerKey,
+		limiter:       limiter,
 	}, nil
 }
 
@@ -126,8 +158,10 @@ func (c *client) Do(ctx context.Context, r *http.Request) (*http.Response, error
 
 func (c *client) doRequest(ctx context.Context, r *http.Request) (resp *http.Response, err error) {
 	var (
-		ht   *nethttp.Tracer
-		body io.ReadCloser
+		ht      *nethttp.Tracer
+		body    io.ReadCloser
+		key     string
+		stopErr error
 	)
 
 	r = r.WithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{}))
@@ -137,7 +171,28 @@ func (c *client) doRequest(ctx context.Context, r *http.Request) (resp *http.Res
 		defer ht.Finish()
 	}
 
+	if c.breaker != nil {
+		key = c.breakerKey(r)
+	}
+
 	action := func() error {
+		if resp != nil {
+			// NOTE: the response of the previous attempt is discarded.
+			drainBody(resp.Body)
+			resp = nil
+		}
+
+		if !c.isAvailable(key) {
+			stopErr = &CircuitOpenError{Key: key}
+			return retry.Unrecoverable(stopErr)
+		}
+
+		if c.limiter != nil {
+			if stopErr = c.limiter.wait(ctx, r); stopErr != nil {
+				return retry.Unrecoverable(stopErr)
+			}
+		}
+
 		// NOTE(a.petrukhin): here we perform copy of current body.
 		// It is faster and consumes less memory to give raw bytes as body and create new reader here.
 		if r.Body != nil && r.GetBody != nil {
@@ -149,42 +204,46 @@ func (c *client) doRequest(ctx context.Context, r *http.Request) (resp *http.Res
 		// If body is provided, we just recreate body in order to have possibility to retry.
 		// For further information see https://stackoverflow.com/questions/31337891/net-http-http-contentlength-222-with-body-length-0
 		//
-		// We manually close the body on retry, otherwise the client must do it himself.
+		// We manually drain and close the body of the discarded response on retry,
+		// otherwise the client must do it himself.
 		resp, err = c.client.Do(r) //nolint:bodyclose
+		c.reportResult(key, resp, err)
 		if err == nil {
-			return nil
+			statusErr := c.statusError(r, resp)
+			if statusErr == nil {
+				return nil
+			}
+			err = statusErr
 		}
 
 		// NOTE(a.petrukhin): setting back because the old body was probably half-read.
 		r.Body = body
 
-		isRetryable := false
-		if v, ok := err.(net.Error); ok && v.Timeout() {
-			// NOTE(a.petrukhin): re-attempt if request timed out.
-			isRetryable = true
-		}
-
-		if isRetryable {
-			return err
-		}
-
-		return retry.Unrecoverable(err)
+		return retry.Classify(c.classifier, err)
 	}
 
 	onRetry := func(n uint, err error) {
 		if c.onRetry != nil {
 			c.onRetry(n, err)
 		}
+	}
 
-		if resp != nil {
-			// If all attempts were failed,
-			// do not close the body for the last response.
-			// It is the user responsibility.
-			_ = resp.Body.Close()
+	err = c.retrier.Do(ctx, action, onRetry)
+	if stopErr != nil {
+		// NOTE: the request was not sent, the error is returned as is.
+		err = stopErr
+	}
+	if resp != nil && err != nil {
+		if ctx.Err() == nil {
+			// NOTE: the retries are over, the last response is returned as is.
+			// It is the user responsibility to close its body.
+			err = nil
+		} else {
+			drainBody(resp.Body)
+			resp = nil
 		}
 	}
 
-	err = c.retrier.Do(ctx, action, onRetry)
 	if err != nil && span != nil {
 		ext.Error.Set(span, true)
 		span.LogFields(log.Error(err))
@@ -193,6 +252,25 @@ func (c *client) doRequest(ctx context.Context, r *http.Request) (resp *http.Res
 	return resp, err
 }
 
+// statusError returns StatusError if the response of the request can be retried.
+func (c *client) statusError(r *http.Request, resp *http.Response) error {
+	if _, ok := c.retryMethods[r.Method]; !ok {
+		return nil
+	}
+
+	// NOTE: the body without GetBody is consumed by the first attempt and can not be sent again.
+	if r.Body != nil && r.GetBody == nil {
+		return nil
+	}
+
+	statusErr := newStatusError(resp, time.Now())
+	if statusErr == nil || statusErr.RetryAfter > c.maxRetryAfter {
+		return nil
+	}
+
+	return statusErr
+}
+
 // Get performs GET-requests with retries for prepared http.Request
 //
 // Get also checks if the given request is a real GET request, otherwise an error is returned.
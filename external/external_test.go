@@ -10,9 +10,12 @@ import (
 	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+
+	"github.com/city-mobil/gobuns/config"
 )
 
 type exSuite struct {
@@ -107,7 +110,7 @@ func (s *exSuite) TestGet() {
 		},
 	}
 
-	cfg := NewConfig("pref")()
+	cfg := NewConfig("pref", config.NewFlagSet("pref", pflag.ContinueOnError))()
 	cfg.RequestTimeout = 50 * time.Millisecond
 	cl, err := New(cfg)
 	require.NoError(t, err)
@@ -313,7 +316,7 @@ func (s *exSuite) TestPostWithRetries() {
 		},
 	}
 
-	cfg := NewConfig("post_with_retries")()
+	cfg := NewConfig("post_with_retries", config.NewFlagSet("post_with_retries", pflag.ContinueOnError))()
 	cfg.RequestTimeout = 50 * time.Millisecond
 	cl, err := New(cfg)
 	require.NoError(t, err)
@@ -0,0 +1,79 @@
+package external
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/city-mobil/gobuns/retry"
+)
+
+// alwaysFail is the amount of failures making the test server fail all the requests.
+const alwaysFail = math.MaxInt32
+
+// newTestServer starts the server which responds with the given status to the first failures requests
+// and echoes the request body afterwards. It returns the server and the counter of the requests.
+func newTestServer(t *testing.T, failures int32, status int, retryAfter string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("failure"))
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+// newTestClient creates the client making the given amount of attempts
+// unless the retry config is set.
+func newTestClient(t *testing.T, attempts int, cfg *Config) Client {
+	t.Helper()
+
+	if cfg.RetryConfig == nil {
+		cfg.RetryConfig = retry.ConfigWithDefaults(&retry.Config{MaxAttempts: attempts}, time.Millisecond)
+	}
+	if cfg.OnRetry == nil {
+		cfg.OnRetry = func(uint, error) {}
+	}
+
+	cl, err := New(cfg)
+	require.NoError(t, err)
+
+	return cl
+}
+
+// doGet performs GET request to the url and returns the status of the response.
+//
+// The body of the response is closed.
+func doGet(ctx context.Context, t *testing.T, cl Client, url string) (int, error) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	resp, err := cl.Get(ctx, req)
+	if err != nil {
+		require.Nil(t, resp)
+		return 0, err
+	}
+	_ = resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
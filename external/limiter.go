@@ -0,0 +1,275 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/city-mobil/gobuns/promlib"
+)
+
+const (
+	// globalLimiterKey is the key of the rate limiter shared by all the hosts.
+	globalLimiterKey = "*"
+
+	defRateLimitIdleTimeout = 10 * time.Minute
+)
+
+// ErrRateLimited is matched by RateLimitedError with errors.Is.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitedError is returned when the request is rejected by the client-side rate limiter.
+type RateLimitedError struct {
+	// Key is the host of the request or "*" for the global rate limiter.
+	Key string
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("%s for upstream %s", ErrRateLimited, e.Key)
+}
+
+// Is reports whether the target is ErrRateLimited.
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RateLimitConfig is a configuration of the client-side token bucket rate limiter.
+type RateLimitConfig struct {
+	// RPS is an amount of requests allowed per second.
+	RPS float64
+
+	// Burst is a maximum amount of requests allowed at once.
+	//
+	// By default: RPS rounded up.
+	Burst int
+
+	// PerHost enables separate limits for each host of the requests,
+	// otherwise the limit is shared by all the requests of the client.
+	PerHost bool
+
+	// Wait makes the request wait until it is allowed, otherwise the request
+	// exceeding the limit fails fast with *RateLimitedError.
+	//
+	// The request fails without waiting if it is not allowed
+	// before the context deadline or MaxWait.
+	Wait bool
+
+	// MaxWait is a maximum time the request waits for the rate limiter.
+	//
+	// Zero means that the wait time is limited by the context only.
+	MaxWait time.Duration
+
+	// IdleTimeout is a period of time after which the limit of unused host is evicted.
+	//
+	// By default: 10m.
+	IdleTimeout time.Duration
+}
+
+func (cfg *RateLimitConfig) withDefaults() RateLimitConfig {
+	c := *cfg
+	if c.Burst <= 0 {
+		c.Burst = int(math.Ceil(c.RPS))
+	}
+	if c.Burst <= 0 {
+		c.Burst = 1
+	}
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = defRateLimitIdleTimeout
+	}
+
+	return c
+}
+
+// tokenBucket is a token bucket which is refilled with rate tokens per second.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// lastUsed is the time of the last request, it is guarded by limiter.mu.
+	lastUsed time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// reserve withdraws a token and returns the time to wait until the token is available.
+//
+// If the wait time exceeds maxWait, no token is withdrawn and false is returned.
+func (b *tokenBucket) reserve(now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+
+	tokens := b.tokens - 1
+	var wait time.Duration
+	if tokens < 0 {
+		wait = time.Duration(-tokens / b.rate * float64(time.Second))
+	}
+	if wait > maxWait {
+		return 0, false
+	}
+
+	b.tokens = tokens
+	return wait, true
+}
+
+// release returns the token of the canceled reservation.
+func (b *tokenBucket) release() {
+	b.mu.Lock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+	b.mu.Unlock()
+}
+
+// limiter is a client-side rate limiter of the requests.
+type limiter struct {
+	cfg     RateLimitConfig
+	metrics *promlib.LimiterMetrics
+
+	mu           sync.Mutex
+	buckets      map[string]*tokenBucket
+	lastEviction time.Time
+}
+
+// newLimiter creates the rate limiter if it is enabled.
+func newLimiter(cfg *Config) (*limiter, error) {
+	if cfg.RateLimit == nil || cfg.RateLimit.RPS <= 0 {
+		return nil, nil
+	}
+
+	l := &limiter{
+		cfg:          cfg.RateLimit.withDefaults(),
+		buckets:      make(map[string]*tokenBucket),
+		lastEviction: time.Now(),
+	}
+	if cfg.Metrics.Collect {
+		metrics, err := promlib.NewLimiterMetrics(cfg.Name, cfg.Metrics.Options...)
+		if err != nil {
+			return nil, err
+		}
+		l.metrics = metrics
+	}
+
+	return l, nil
+}
+
+// key returns the key of the request bucket.
+func (l *limiter) key(r *http.Request) string {
+	if l.cfg.PerHost {
+		return HostKey(r)
+	}
+
+	return globalLimiterKey
+}
+
+func (l *limiter) bucket(key string, now time.Time) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		l.evictIdle(now)
+
+		b = newTokenBucket(l.cfg.RPS, l.cfg.Burst, now)
+		l.buckets[key] = b
+	}
+	b.lastUsed = now
+
+	return b
+}
+
+// evictIdle removes the buckets which have not been used for IdleTimeout.
+//
+// Idle buckets are checked only when a new key appears and no more often than once per IdleTimeout.
+// It MUST be called under mu.
+func (l *limiter) evictIdle(now time.Time) {
+	if now.Sub(l.lastEviction) < l.cfg.IdleTimeout {
+		return
+	}
+	l.lastEviction = now
+
+	for k, b := range l.buckets {
+		if now.Sub(b.lastUsed) >= l.cfg.IdleTimeout {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// maxWait returns the maximum time the request can wait for the token.
+func (l *limiter) maxWait(ctx context.Context, now time.Time) time.Duration {
+	if !l.cfg.Wait {
+		return 0
+	}
+
+	wait := time.Duration(math.MaxInt64)
+	if l.cfg.MaxWait > 0 {
+		wait = l.cfg.MaxWait
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(now) < wait {
+		wait = deadline.Sub(now)
+	}
+
+	return wait
+}
+
+// wait blocks until the request is allowed by the rate limiter.
+//
+// It returns *RateLimitedError if the request is not allowed in time
+// or the context error if the context is done before or while waiting.
+func (l *limiter) wait(ctx context.Context, r *http.Request) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	key := l.key(r)
+	b := l.bucket(key, now)
+
+	wait, ok := b.reserve(now, l.maxWait(ctx, now))
+	if !ok {
+		// NOTE: the request with the expired deadline is not rejected by the limiter,
+		// even if the context is not done yet.
+		if deadline, has := ctx.Deadline(); has && !deadline.After(now) {
+			return context.DeadlineExceeded
+		}
+
+		if l.metrics != nil {
+			l.metrics.IncRejected(key)
+		}
+		return &RateLimitedError{Key: key}
+	}
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			b.release()
+			return ctx.Err()
+		}
+	}
+
+	if l.metrics != nil {
+		l.metrics.ObserveWait(key, wait)
+	}
+
+	return nil
+}
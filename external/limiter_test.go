@@ -0,0 +1,186 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/city-mobil/gobuns/barber"
+	"github.com/city-mobil/gobuns/config"
+	"github.com/city-mobil/gobuns/promlib"
+)
+
+func TestClient_RateLimitFailFast(t *testing.T) {
+	srv, _ := newTestServer(t, 0, 0, "")
+	other, _ := newTestServer(t, 0, 0, "")
+
+	reg := prometheus.NewRegistry()
+	cl := newTestClient(t, 1, &Config{
+		Name: "limited",
+		RateLimit: &RateLimitConfig{
+			RPS:     1,
+			Burst:   2,
+			PerHost: true,
+		},
+		Metrics: ConfigMetrics{
+			Collect: true,
+			Options: []promlib.InstrumentOption{promlib.InstrumentWithRegisterer(reg)},
+		},
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := doGet(ctx, t, cl, srv.URL)
+		require.NoError(t, err)
+	}
+
+	_, err := doGet(ctx, t, cl, srv.URL)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	var limitErr *RateLimitedError
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, srv.Listener.Addr().String(), limitErr.Key)
+
+	// NOTE: other hosts have their own limits.
+	_, err = doGet(ctx, t, cl, other.URL)
+	require.NoError(t, err)
+
+	rejected, err := testutil.GatherAndCount(reg, "limited_rate_limiter_rejected_total")
+	require.NoError(t, err)
+	assert.Equal(t, 1, rejected)
+}
+
+func TestClient_RateLimitWait(t *testing.T) {
+	srv, _ := newTestServer(t, 0, 0, "")
+	cl := newTestClient(t, 1, &Config{
+		RateLimit: &RateLimitConfig{
+			RPS:  20,
+			Wait: true,
+		},
+	})
+
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 22; i++ {
+		_, err := doGet(ctx, t, cl, srv.URL)
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	// NOTE: the request is rejected at once if it is not allowed before the deadline.
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	start = time.Now()
+	_, err := doGet(ctx, t, cl, srv.URL)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.Less(t, time.Since(start), 10*time.Millisecond)
+}
+
+func TestLimiter_EvictIdle(t *testing.T) {
+	l, err := newLimiter(&Config{
+		RateLimit: &RateLimitConfig{
+			RPS:         1,
+			PerHost:     true,
+			IdleTimeout: time.Minute,
+		},
+	})
+	require.NoError(t, err)
+
+	now := l.lastEviction
+	l.bucket("first", now)
+	l.bucket("second", now.Add(30*time.Second))
+	l.bucket("third", now.Add(59*time.Second))
+	assert.Len(t, l.buckets, 3, "idle buckets are checked no more often than once per IdleTimeout")
+
+	l.bucket("first", now.Add(80*time.Second))
+	l.bucket("fourth", now.Add(2*time.Minute))
+	assert.Len(t, l.buckets, 2)
+	assert.Contains(t, l.buckets, "first")
+	assert.Contains(t, l.buckets, "fourth")
+}
+
+func TestNew_RateLimitMetricsRegistered(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	conflict := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "limited_rate_limiter_rejected_total",
+		Help: "A counter for requests rejected by the rate limiter.",
+	}, []string{"host"})
+	reg.MustRegister(conflict)
+
+	cfg := &Config{
+		Name:           "limited",
+		RateLimit:      &RateLimitConfig{RPS: 1},
+		CircuitBreaker: &barber.Config{},
+		Metrics: ConfigMetrics{
+			Collect: true,
+			Options: []promlib.InstrumentOption{promlib.InstrumentWithRegisterer(reg)},
+		},
+	}
+	_, err := New(cfg)
+	assert.Error(t, err)
+
+	// NOTE: the metrics registered before the failure are unregistered.
+	reg.Unregister(conflict)
+	_, err = New(cfg)
+	assert.NoError(t, err)
+}
+
+func TestLimiter_WaitExpiredDeadline(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	l, err := newLimiter(&Config{
+		Name:      "limited",
+		RateLimit: &RateLimitConfig{RPS: 1, Wait: true},
+		Metrics: ConfigMetrics{
+			Collect: true,
+			Options: []promlib.InstrumentOption{promlib.InstrumentWithRegisterer(reg)},
+		},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.NoError(t, l.wait(context.Background(), req))
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	err = l.wait(ctx, req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, errors.Is(err, ErrRateLimited))
+
+	rejected, err := testutil.GatherAndCount(reg, "limited_rate_limiter_rejected_total")
+	require.NoError(t, err)
+	assert.Equal(t, 0, rejected)
+}
+
+func TestNewConfig_RateLimit(t *testing.T) {
+	fs := config.NewFlagSet("external_rate_limit", pflag.ContinueOnError)
+	cfgFn := NewConfig("partner", fs)
+	defCfgFn := NewConfig("default", fs)
+
+	require.NoError(t, fs.Init(nil,
+		"--partner.external.rate_limit.rps=2.5",
+		"--partner.external.rate_limit.per_host=true",
+		"--partner.external.rate_limit.wait=true",
+		"--partner.external.rate_limit.max_wait=1s",
+	))
+
+	assert.Equal(t, &RateLimitConfig{
+		RPS:         2.5,
+		PerHost:     true,
+		Wait:        true,
+		MaxWait:     time.Second,
+		IdleTimeout: defRateLimitIdleTimeout,
+	}, cfgFn().RateLimit)
+	assert.Equal(t, 3, cfgFn().RateLimit.withDefaults().Burst)
+	assert.Nil(t, defCfgFn().RateLimit)
+}
@@ -0,0 +1,102 @@
+package external
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/city-mobil/gobuns/zlog"
+)
+
+// Middleware wraps the transport of the client to intercept each request attempt.
+//
+// Middlewares must not modify the given request, use http.Request.Clone
+// to change headers.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to use ordinary functions as http.RoundTripper.
+type RoundTripperFunc func(r *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(r).
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// chain wraps the transport with the middlewares, the first middleware is the outermost one.
+func chain(tr http.RoundTripper, mws []Middleware) http.RoundTripper {
+	for i := len(mws) - 1; i >= 0; i-- {
+		tr = mws[i](tr)
+	}
+
+	return tr
+}
+
+// HeaderMiddleware sets the header to each request, e.g. the authorization token.
+func HeaderMiddleware(key, value string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			r = r.Clone(r.Context())
+			r.Header.Set(key, value)
+			return next.RoundTrip(r)
+		})
+	}
+}
+
+// ContextHeaderMiddleware sets the header to the value returned by fn
+// for the request context, e.g. to propagate the request ID.
+//
+// The header is not set if the request already has it or fn returns an empty string.
+func ContextHeaderMiddleware(key string, fn func(ctx context.Context) string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Header.Get(key) != "" {
+				return next.RoundTrip(r)
+			}
+
+			val := fn(r.Context())
+			if val == "" {
+				return next.RoundTrip(r)
+			}
+
+			r = r.Clone(r.Context())
+			r.Header.Set(key, val)
+			return next.RoundTrip(r)
+		})
+	}
+}
+
+// LoggingMiddleware logs each request attempt with its status and duration.
+//
+// Successful requests are logged with the debug level, failed requests and
+// 5xx responses with the warn level. If log is nil, the logger of the request
+// context is used.
+func LoggingMiddleware(log zlog.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(r)
+
+			logger := log
+			if logger == nil {
+				logger = zlog.FromContext(r.Context())
+			}
+
+			var event *zlog.Event
+			switch {
+			case err != nil:
+				event = logger.Warn().Err(err)
+			case resp.StatusCode >= http.StatusInternalServerError:
+				event = logger.Warn().Int("status", resp.StatusCode)
+			default:
+				event = logger.Debug().Int("status", resp.StatusCode)
+			}
+			event.
+				Str("method", r.Method).
+				Str("url", r.URL.Redacted()).
+				Dur("duration", time.Since(start)).
+				Msg("external request")
+
+			return resp, err
+		})
+	}
+}
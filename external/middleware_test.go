@@ -0,0 +1,76 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/city-mobil/gobuns/zlog"
+)
+
+type requestIDKey struct{}
+
+func TestClient_Middlewares(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "42", r.Header.Get("X-Request-Id"))
+		assert.Equal(t, "first,second", r.Header.Get("X-Order"))
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	order := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				r = r.Clone(r.Context())
+				val := name
+				if v := r.Header.Get("X-Order"); v != "" {
+					val = v + "," + name
+				}
+				r.Header.Set("X-Order", val)
+				return next.RoundTrip(r)
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	cl := newTestClient(t, 2, &Config{
+		Middlewares: []Middleware{
+			HeaderMiddleware("Authorization", "Bearer token"),
+			ContextHeaderMiddleware("X-Request-Id", func(ctx context.Context) string {
+				id, _ := ctx.Value(requestIDKey{}).(string)
+				return id
+			}),
+			LoggingMiddleware(zlog.New(&buf)),
+			order("first"),
+			order("second"),
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "42")
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/orders?token=secret", nil)
+	require.NoError(t, err)
+
+	resp, err := cl.Get(ctx, req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, attempts)
+	// NOTE: the middlewares do not modify the request of the caller.
+	assert.Empty(t, req.Header)
+
+	logs := buf.String()
+	assert.Contains(t, logs, `"level":"warn","status":503`)
+	assert.Contains(t, logs, `"level":"debug","status":200`)
+	assert.Contains(t, logs, `"method":"GET"`)
+}
@@ -0,0 +1,133 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/city-mobil/gobuns/retry"
+)
+
+// maxDrainSize is a maximum amount of bytes read from the discarded response body
+// to reuse the connection. Connections of the larger bodies are closed.
+const maxDrainSize = 4 << 10
+
+var (
+	// DefaultRetryStatusCodes are response status codes retried by default.
+	DefaultRetryStatusCodes = []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+
+	// DefaultRetryMethods are idempotent request methods retried on status codes by default.
+	DefaultRetryMethods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodOptions,
+		http.MethodTrace,
+		http.MethodPut,
+		http.MethodDelete,
+	}
+
+	// DefaultClassifier classifies network timeouts and responses
+	// with DefaultRetryStatusCodes as retryable.
+	DefaultClassifier = retry.AnyOf(
+		retry.NetTimeout,
+		retry.MatchCodes(statusCode, DefaultRetryStatusCodes...),
+	)
+)
+
+// NewClassifier returns the classifier of network timeouts and responses
+// with the given status codes, the codes replace DefaultRetryStatusCodes.
+//
+// DefaultClassifier is returned if no codes are given.
+func NewClassifier(codes ...int) retry.Classifier {
+	if len(codes) == 0 {
+		return DefaultClassifier
+	}
+
+	return retry.AnyOf(retry.NetTimeout, retry.MatchCodes(statusCode, codes...))
+}
+
+// StatusError is an error passed to the retry policy
+// when a request of the retryable method got the error response.
+//
+// It is never returned to the caller: if the retries are over,
+// the last response is returned as is.
+type StatusError struct {
+	// StatusCode is the response status code.
+	StatusCode int
+
+	// RetryAfter is the delay requested by the Retry-After response header.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response status: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// RetryDelay implements retry.Delayer.
+func (e *StatusError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// StatusCode returns the response status code for the error, or zero
+// if the error is not a StatusError.
+func StatusCode(err error) int {
+	var val *StatusError
+	if errors.As(err, &val) {
+		return val.StatusCode
+	}
+	return 0
+}
+
+func statusCode(err error) (int, bool) {
+	code := StatusCode(err)
+	return code, code != 0
+}
+
+// newStatusError returns StatusError for the error response or nil otherwise.
+func newStatusError(resp *http.Response, now time.Time) *StatusError {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), now),
+	}
+}
+
+// parseRetryAfter parses the value of the Retry-After header
+// given in seconds or as HTTP date. Invalid values are ignored.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(v)
+	if err != nil || !date.After(now) {
+		return 0
+	}
+
+	return date.Sub(now)
+}
+
+// drainBody reads the rest of the discarded response body
+// to reuse the connection and closes it.
+func drainBody(body io.ReadCloser) {
+	_, _ = io.CopyN(io.Discard, body, maxDrainSize)
+	_ = body.Close()
+}
@@ -0,0 +1,212 @@
+package external
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RetryStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		status     int
+		failures   int32
+		cfg        *Config
+		wantStatus int
+		wantCalls  int32
+	}{
+		{
+			name:       "ServiceUnavailable",
+			method:     http.MethodPut,
+			status:     http.StatusServiceUnavailable,
+			failures:   2,
+			cfg:        &Config{},
+			wantStatus: http.StatusOK,
+			wantCalls:  3,
+		},
+		{
+			name:       "AttemptsExhausted",
+			method:     http.MethodGet,
+			status:     http.StatusBadGateway,
+			failures:   5,
+			cfg:        &Config{},
+			wantStatus: http.StatusBadGateway,
+			wantCalls:  3,
+		},
+		{
+			name:       "NotRetryableStatus",
+			method:     http.MethodGet,
+			status:     http.StatusInternalServerError,
+			failures:   1,
+			cfg:        &Config{},
+			wantStatus: http.StatusInternalServerError,
+			wantCalls:  1,
+		},
+		{
+			name:     "RetryStatusCodes",
+			method:   http.MethodGet,
+			status:   http.StatusInternalServerError,
+			failures: 1,
+			cfg: &Config{
+				RetryStatusCodes: []int{http.StatusInternalServerError},
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name:     "RetryStatusCodesReplaceDefaults",
+			method:   http.MethodGet,
+			status:   http.StatusServiceUnavailable,
+			failures: 1,
+			cfg: &Config{
+				RetryStatusCodes: []int{http.StatusInternalServerError},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantCalls:  1,
+		},
+		{
+			name:     "StatusRetriesDisabled",
+			method:   http.MethodGet,
+			status:   http.StatusServiceUnavailable,
+			failures: 1,
+			cfg: &Config{
+				RetryMethods: []string{},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantCalls:  1,
+		},
+		{
+			name:       "NotIdempotentMethod",
+			method:     http.MethodPost,
+			status:     http.StatusServiceUnavailable,
+			failures:   1,
+			cfg:        &Config{},
+			wantStatus: http.StatusServiceUnavailable,
+			wantCalls:  1,
+		},
+		{
+			name:     "RetryMethods",
+			method:   http.MethodPost,
+			status:   http.StatusServiceUnavailable,
+			failures: 1,
+			cfg: &Config{
+				RetryMethods: []string{"post"},
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := newTestServer(t, tt.failures, tt.status, "")
+			cl := newTestClient(t, 3, tt.cfg)
+
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader("payload"))
+			require.NoError(t, err)
+
+			resp, err := cl.Do(context.Background(), req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantCalls, atomic.LoadInt32(calls))
+
+			// NOTE: the body of the returned response is not closed.
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, "payload", string(body))
+			} else {
+				assert.Equal(t, "failure", string(body))
+			}
+		})
+	}
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	srv, calls := newTestServer(t, 1, http.StatusTooManyRequests, "1")
+	cl := newTestClient(t, 3, &Config{
+		RequestTimeout: time.Second,
+		MaxRetryAfter:  2 * time.Second,
+	})
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	resp, err := cl.Get(context.Background(), req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestClient_RetryAfterTooLong(t *testing.T) {
+	srv, calls := newTestServer(t, 1, http.StatusServiceUnavailable, "120")
+	cl := newTestClient(t, 3, &Config{})
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := cl.Get(context.Background(), req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestClient_RetryStatusNotReplayableBody(t *testing.T) {
+	srv, calls := newTestServer(t, 1, http.StatusServiceUnavailable, "")
+	cl := newTestClient(t, 3, &Config{})
+
+	req, err := http.NewRequest(http.MethodPut, srv.URL, io.NopCloser(strings.NewReader("payload")))
+	require.NoError(t, err)
+	require.Nil(t, req.GetBody)
+
+	resp, err := cl.Do(context.Background(), req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// NOTE: the consumed body can not be sent again, so the status is not retried.
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestClient_RetryStatusContextCanceled(t *testing.T) {
+	srv, _ := newTestServer(t, 5, http.StatusServiceUnavailable, "1")
+	cl := newTestClient(t, 3, &Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := cl.Get(ctx, req) //nolint:bodyclose
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, resp)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-3", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
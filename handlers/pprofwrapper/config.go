@@ -3,4 +3,6 @@ package pprofwrapper
 type Config struct {
 	Port           string
 	CmdlineEnabled bool
+	// ConfigEnabled registers the dump of the global configuration.
+	ConfigEnabled bool
 }
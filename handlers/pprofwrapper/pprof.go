@@ -6,6 +6,8 @@ import (
 	"net/http/pprof"
 
 	"github.com/gorilla/mux"
+
+	"github.com/city-mobil/gobuns/config"
 )
 
 type Mux interface {
@@ -21,6 +23,9 @@ func register(cfg *Config, mx Mux) {
 	if cfg.CmdlineEnabled {
 		mx.Handle("/debug/cmdline", pprof.Handler("cmdline"))
 	}
+	if cfg.ConfigEnabled {
+		mx.Handle("/debug/config", config.Handler())
+	}
 
 	mx.Handle("/debug/goroutine", pprof.Handler("goroutine"))
 	mx.Handle("/debug/heap", pprof.Handler("heap"))
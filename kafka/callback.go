@@ -8,13 +8,16 @@ func CompletionCallbackDiscard(_ []kafka.Message, _ error) {
 }
 
 type completionCallbackOnError struct {
-	onErr func(error)
-	next  CompletionCallback
+	onErr     func(error)
+	onSuccess func()
+	next      CompletionCallback
 }
 
 func (cb *completionCallbackOnError) exec(messages []kafka.Message, err error) {
 	if err != nil {
 		cb.onErr(err)
+	} else if cb.onSuccess != nil {
+		cb.onSuccess()
 	}
 
 	if cb.next != nil {
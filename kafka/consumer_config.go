@@ -56,17 +56,17 @@ type ConsumerConfig struct {
 	ErrorLogLevel          zlog.Level
 }
 
-func newDialerConfig(prefix string) func() *ConsumerDialerConfig {
+func newDialerConfig(prefix string, fs *config.FlagSet) func() *ConsumerDialerConfig {
 	o := func(opt string) string {
 		return prefix + "dialer." + opt
 	}
 
 	var (
-		clientID      = config.String(o("client_id"), "", "Kafka Consumer Dialer ClientID.")
-		timeout       = config.Duration(o("timeout"), 0, "Kafka Consumer Dialer Timeout.")
-		localAddr     = config.String(o("local_addr"), "", "Kafka Consumer Dialer LocalAddr.")
-		fallbackDelay = config.Duration(o("fallback_delay"), 0, "Kafka Consumer FallbackDelay.")
-		keepAlive     = config.Duration("keep_alive", 0, "Kafka Consumer KeepAlive.")
+		clientID      = fs.String(o("client_id"), "", "Kafka Consumer Dialer ClientID.")
+		timeout       = fs.Duration(o("timeout"), 0, "Kafka Consumer Dialer Timeout.")
+		localAddr     = fs.String(o("local_addr"), "", "Kafka Consumer Dialer LocalAddr.")
+		fallbackDelay = fs.Duration(o("fallback_delay"), 0, "Kafka Consumer FallbackDelay.")
+		keepAlive     = fs.Duration("keep_alive", 0, "Kafka Consumer KeepAlive.")
 	)
 
 	return func() *ConsumerDialerConfig {
@@ -80,7 +80,9 @@ func newDialerConfig(prefix string) func() *ConsumerDialerConfig {
 	}
 }
 
-func NewConsumerConfig(prefix string) func() *ConsumerConfig {
+func NewConsumerConfig(prefix string, set ...*config.FlagSet) func() *ConsumerConfig {
+	fs := config.FlagSetOrDefault(set...)
+
 	if prefix != "" {
 		prefix += ".kafka.consumer."
 	} else {
@@ -100,138 +102,138 @@ func NewConsumerConfig(prefix string) func() *ConsumerConfig {
 	}
 
 	var (
-		groupID = config.String(
+		groupID = fs.String(
 			o("consumer.group_id"),
 			"",
 			"Kafka consumer group_id.",
 		)
-		brokers = config.StringSlice(
+		brokers = fs.StringSlice(
 			o("consumer.brokers"),
 			[]string{},
 			"Kafka consumer brokers.",
 		)
-		topic = config.String(
+		topic = fs.String(
 			o("consumer.topic"),
 			"",
 			"Kafka consumer topic.",
 		)
-		partition = config.Int(
+		partition = fs.Int(
 			o("consumer.partition"),
 			0,
 			"Kafka Consumer Partition.",
 		)
-		dialerConfig = newDialerConfig(o("consumer.dialer"))
-		dialTimeout  = config.Duration(
+		dialerConfig = newDialerConfig(o("consumer.dialer"), fs)
+		dialTimeout  = fs.Duration(
 			o("consumer.net.dial_timeout"),
 			defaultDialTimeout,
 			"Kafka consumer dial timeout.",
 		)
-		queueCapacity = config.Int(
+		queueCapacity = fs.Int(
 			o("consumer.queue_capacity"),
 			consumerDefaultQueueCapacity,
 			"Kafka consumer internal queue capacity",
 		)
-		minBytes = config.Int(
+		minBytes = fs.Int(
 			o("consumer.fetch.min_bytes"),
 			consumerDefaultMinBytes,
 			"Kafka consumer min bytes to fetch on each request",
 		)
-		maxBytes = config.Int(
+		maxBytes = fs.Int(
 			o("consumer.fetch.max_bytes"),
 			consumerDefaultMaxBytes,
 			"Kafka consumer max bytes to fetch on each request.",
 		)
-		maxWait = config.Duration(
+		maxWait = fs.Duration(
 			o("consumer.max_wait"),
 			consumerDefaultMaxWait,
 			"Consumer Max Wait.",
 		)
-		readLagInterval = config.Duration(
+		readLagInterval = fs.Duration(
 			o("consumer.read_lag_interval"),
 			consumerDefaultReadLagInterval,
 			"Consumer frequency at which the reader lag is updated.",
 		)
-		groupBalancers = config.UintSlice(
+		groupBalancers = fs.UintSlice(
 			o("consumer.group_balancers"),
 			[]uint{uint(GroupBalancerRange), uint(GroupBalancerRoundRobin)},
 			"Consumer Group Balancers.",
 		)
-		heartBeatInterval = config.Duration(
+		heartBeatInterval = fs.Duration(
 			o("consumer.heart_beat_interval"),
 			0,
 			"Consumer Heartbeat Interval.",
 		)
-		commitInterval = config.Duration(
+		commitInterval = fs.Duration(
 			o("consumer.commit_interval"),
 			0,
 			"Consumer Commit Interval.",
 		)
-		partitionWatchInterval = config.Duration(
+		partitionWatchInterval = fs.Duration(
 			o("consumer.partition_watch_interval"),
 			0,
 			"Consumer Partition Watch Interval.",
 		)
-		watchPartitionChanges = config.Bool(
+		watchPartitionChanges = fs.Bool(
 			o("watch_partition_changes"),
 			false,
 			"Kafka Consumer WatchPartitionChanges",
 		)
-		sessionTimeout = config.Duration(
+		sessionTimeout = fs.Duration(
 			o("consumer.session_timeout"),
 			0,
 			"Consumer Session Timeout.",
 		)
-		rebalanceTimeout = config.Duration(
+		rebalanceTimeout = fs.Duration(
 			o("consumer.rebalance_timeout"),
 			0,
 			"Consumer Rebalance Timeout.",
 		)
-		joinGroupBackoff = config.Duration(
+		joinGroupBackoff = fs.Duration(
 			o("consumer.join_group_backoff"),
 			0,
 			"Consumer Join Group Backoff.",
 		)
-		retentionTime = config.Duration(
+		retentionTime = fs.Duration(
 			o("consumer.retention_time"),
 			0,
 			"Consumer Retention Time.",
 		)
-		startOffset = config.Int64(
+		startOffset = fs.Int64(
 			o("consumer.start_offset"),
 			0,
 			"Consumer Start Offset.",
 		)
-		readBackoffMin = config.Duration(
+		readBackoffMin = fs.Duration(
 			o("consumer.read_backoff_min"),
 			0,
 			"Consumer Read Backoff Min.",
 		)
-		readBackoffMax = config.Duration(
+		readBackoffMax = fs.Duration(
 			o("consumer.read_backoff_max"),
 			0,
 			"Consumer Read Backoff Max.",
 		)
-		isolationLevel = config.Int8(
+		isolationLevel = fs.Int8(
 			o("consumer.isolation_level"),
 			0,
 			"Consumer Isolation Level.",
 		)
-		maxAttempts = config.Int(
+		maxAttempts = fs.Int(
 			o("consumer.max_attempts"),
 			0,
 			"Consumer Max Attempts.",
 		)
-		logLevel = config.Int8(
+		logLevel = fs.Int8(
 			o("log.level"),
 			int8(defaultLogLevel),
 			"Kafka consumer logger log level.",
 		)
-		errorLogLevel = config.Int8(
+		errorLogLevel = fs.Int8(
 			o("log.errors_level"),
 			int8(defaultLogLevel),
 			"Kafka consumer error-logger log level.",
 		)
-		statsConfig = newStatsConfig(o("consumer."))
+		statsConfig = newStatsConfig(o("consumer."), fs)
 	)
 
 	return func() *ConsumerConfig {
@@ -81,15 +81,28 @@ func (p *producer) Produce(ctx context.Context, messages ...kafka.Message) error
 	}
 
 	if p.config.CircuitBreakerEnabled {
-		alive := p.breaker.IsAvailable(breakerServerID, time.Now())
+		alive := p.breaker.Allow(breakerServerID, time.Now())
 		if !alive {
 			return ErrBrokerUnavailable
 		}
 	}
 
 	err := p.writer.WriteMessages(ctx, messages...)
+
+	// NOTE: asynchronous writer returns before the messages are delivered,
+	// so the delivery result is reported to the breaker by the completion callback only.
+	// The completion callback is not called for the messages rejected by WriteMessages.
+	if p.writer.Async {
+		if err != nil {
+			p.breaker.AddError(breakerServerID, time.Now())
+		}
+		return err
+	}
+
 	if err != nil {
 		p.breaker.AddError(breakerServerID, time.Now())
+	} else {
+		p.breaker.AddSuccess(breakerServerID, time.Now())
 	}
 
 	return err
@@ -167,6 +180,9 @@ func newOnErrorCallbackWrapper(breaker barber.Barber, next CompletionCallback) C
 		onErr: func(_ error) {
 			breaker.AddError(breakerServerID, time.Now())
 		},
+		onSuccess: func() {
+			breaker.AddSuccess(breakerServerID, time.Now())
+		},
 		next: next,
 	}
 
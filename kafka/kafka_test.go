@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	metadataAPI "github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/city-mobil/gobuns/barber"
+	"github.com/city-mobil/gobuns/zlog"
+)
+
+// failingTransport knows the partitions of the topics, but fails to deliver any message.
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(_ context.Context, _ net.Addr, req kafka.Request) (kafka.Response, error) {
+	if r, ok := req.(*metadataAPI.Request); ok {
+		resp := &metadataAPI.Response{}
+		for _, name := range r.TopicNames {
+			resp.Topics = append(resp.Topics, metadataAPI.ResponseTopic{
+				Name:       name,
+				Partitions: []metadataAPI.ResponsePartition{{}},
+			})
+		}
+		return resp, nil
+	}
+
+	return nil, errors.New("broker is down")
+}
+
+func TestAsyncProducer_BreakerStaysOpenOnFailedDeliveries(t *testing.T) {
+	p := NewAsyncProducer(zlog.Nop(), &ProducerConfig{
+		Brokers:               []string{"127.0.0.1:9092"},
+		MaxRetries:            1,
+		QueueBufferingTimeout: time.Millisecond,
+		StatsConfig:           &StatsConfig{},
+		CircuitBreakerEnabled: true,
+		CircuitBreakerConfig: &barber.Config{
+			Threshold:   10,
+			MaxFails:    1,
+			OpenTimeout: 50 * time.Millisecond,
+		},
+	})
+	defer p.Close()
+	p.(*producer).writer.Transport = failingTransport{}
+
+	delivered := make(chan error, 10)
+	p.SetCompletionCallback(func(_ []kafka.Message, err error) {
+		delivered <- err
+	})
+
+	produce := func() {
+		require.NoError(t, p.Produce(context.Background(), kafka.Message{Topic: "orders", Value: []byte("1")}))
+		select {
+		case err := <-delivered:
+			require.Error(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("delivery is not completed")
+		}
+	}
+
+	produce()
+	produce()
+	assert.Equal(t, ErrBrokerUnavailable, p.Ping(), "expected broker to be unavailable")
+	assert.Equal(t, ErrBrokerUnavailable, p.Produce(context.Background(), kafka.Message{Topic: "orders"}), "expected breaker to be opened")
+
+	// NOTE: the trial messages are accepted by the async writer, but their delivery fails.
+	time.Sleep(60 * time.Millisecond)
+	produce()
+	assert.Equal(t, ErrBrokerUnavailable, p.Ping(), "expected breaker to stay opened")
+}
@@ -48,7 +48,9 @@ type ProducerConfig struct { //nolint:maligned
 	CircuitBreakerEnabled bool
 }
 
-func NewProducerConfig(prefix string) func() *ProducerConfig {
+func NewProducerConfig(prefix string, set ...*config.FlagSet) func() *ProducerConfig {
+	fs := config.FlagSetOrDefault(set...)
+
 	if prefix != "" {
 		prefix += ".kafka.producer."
 	} else {
@@ -60,77 +62,77 @@ func NewProducerConfig(prefix string) func() *ProducerConfig {
 	}
 
 	var (
-		brokers = config.StringSlice(
+		brokers = fs.StringSlice(
 			o("producer.brokers"),
 			[]string{},
 			"Kafka broker addresses.",
 		)
-		balancer = config.String(
+		balancer = fs.String(
 			o("producer.balancer"),
 			BalancerRoundRobin,
 			"Kafka producer balancer",
 		)
-		maxRetries = config.Int(
+		maxRetries = fs.Int(
 			o("producer.max_retries"),
 			defaultMaxRetries,
 			"Kafka message max retries. Analog for "+
 				"messages.send.max.retries rdkafka option.",
 		)
-		maxMessages = config.Int(
+		maxMessages = fs.Int(
 			o("producer.queue.max_messages"),
 			defaultQueueMaxMessages,
 			"Kafka producer internal "+
 				"queue max messages. Analog for queue.buffering.max.messages rdkafka option.",
 		)
-		maxQueueSize = config.Int64(
+		maxQueueSize = fs.Int64(
 			o("producer.queue.max_bytes"),
 			defaultQueueBufferMaxSize,
 			"Kafka producer "+
 				"internal queue max size in bytes. Analog for queue.buffering.max.kbytes rdkafka option.",
 		)
-		queueBufferingTimeout = config.Duration(
+		queueBufferingTimeout = fs.Duration(
 			o("producer.queue.buffering_timeout"),
 			defaultQueueBufferingTimeout,
 			"Kafka producer internal queue max buffering timeout. Analog for queue.buffering.max.ms rdkafka"+
 				"option.",
 		)
-		readTimeout = config.Duration(
+		readTimeout = fs.Duration(
 			o("producer.net.read_timeout"),
 			defaultReadTimeout,
 			"Kafka producer network read timeout.")
-		writeTimeout = config.Duration(
+		writeTimeout = fs.Duration(
 			o("producer.net.write_timeout"),
 			defaultWriteTimeout,
 			"Kafka producer network write timeout.",
 		)
-		dialTimeout = config.Duration(
+		dialTimeout = fs.Duration(
 			o("producer.net.dial_timeout"),
 			defaultDialTimeout,
 			"Kafka producer network dial timeout.",
 		)
-		requiredAcks = config.Int(
+		requiredAcks = fs.Int(
 			o("producer.required_acks"),
 			0,
 			"Kafka producer required acks. Analog for request.required.acks rdkafka option.",
 		)
-		compression = config.Int8(
+		compression = fs.Int8(
 			o("producer.compression"),
 			defaultCompression,
 			"Kafka producer compression. Analog for compression.codec rdkafka option.",
 		)
-		logLevel = config.Int8(
+		logLevel = fs.Int8(
 			o("log.level"),
 			int8(defaultLogLevel),
 			"Kafka producer logger log level.",
 		)
-		errorLogLevel = config.Int8(
+		errorLogLevel = fs.Int8(
 			o("log.errors_level"),
 			int8(defaultLogLevel),
 			"Kafka producer error-logger log level",
 		)
-		statsConfig    = newStatsConfig("")
-		breakerEnabled = config.Bool(o("breaker.enabled"), defaultCircuitBreakerEnabled, "Kafka circuit breaker mode.")
-		breakerConfig  = barber.NewConfig(o("breaker"))
+		statsConfig    = newStatsConfig("", fs)
+		breakerEnabled = fs.Bool(o("breaker.enabled"), defaultCircuitBreakerEnabled, "Kafka circuit breaker mode.")
+		breakerConfig  = barber.NewConfig(o("breaker"), fs)
 	)
 
 	return func() *ProducerConfig {
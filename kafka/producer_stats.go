@@ -18,10 +18,10 @@ type StatsConfig struct {
 	Enabled         bool
 }
 
-func newStatsConfig(prefix string) func() *StatsConfig {
+func newStatsConfig(prefix string, fs *config.FlagSet) func() *StatsConfig {
 	var (
-		enabled         = config.Bool(prefix+"stats.enabled", defaultStatsCollectionEnabled, "Kafka producer stats enabled.")
-		refreshInterval = config.Duration(prefix+"stats.refresh_interval", defaultStatsRefreshInterval, "Kafka producer stats refresh interval")
+		enabled         = fs.Bool(prefix+"stats.enabled", defaultStatsCollectionEnabled, "Kafka producer stats enabled.")
+		refreshInterval = fs.Duration(prefix+"stats.refresh_interval", defaultStatsRefreshInterval, "Kafka producer stats refresh interval")
 	)
 
 	return func() *StatsConfig {
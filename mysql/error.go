@@ -2,9 +2,12 @@ package mysql
 
 import (
 	"database/sql/driver"
+	"errors"
 	"net"
 
 	"github.com/go-sql-driver/mysql"
+
+	"github.com/city-mobil/gobuns/retry"
 )
 
 var (
@@ -17,10 +20,43 @@ var (
 	}
 )
 
+var (
+	// DefaultClassifier classifies connection errors and some MySQL server errors as retryable.
+	DefaultClassifier = retry.AnyOf(
+		retry.MatchErrors(mysql.ErrInvalidConn, driver.ErrBadConn),
+		retry.ClassifierFunc(func(err error) bool {
+			// Being unable to reach MySQL is a network issue, so we get a net.OpError.
+			// If MySQL is reachable, then we'd get a mysql.* or driver.* error instead.
+			var opErr *net.OpError
+			return errors.As(err, &opErr)
+		}),
+		retry.ClassifierFunc(func(err error) bool {
+			_, ok := retryableErrors[ErrorCode(err)]
+			return ok
+		}),
+	)
+)
+
+// NewClassifier returns DefaultClassifier extended with the given MySQL server error codes,
+// e.g. 1213 (ER_LOCK_DEADLOCK).
+func NewClassifier(codes ...uint16) retry.Classifier {
+	if len(codes) == 0 {
+		return DefaultClassifier
+	}
+
+	return retry.AnyOf(DefaultClassifier, retry.MatchCodes(errorCode, codes...))
+}
+
+func errorCode(err error) (uint16, bool) {
+	code := ErrorCode(err)
+	return code, code != 0
+}
+
 // ErrorCode returns the MySQL server error code for the error, or zero
 // if the error is not a MySQL error.
 func ErrorCode(err error) uint16 {
-	if val, ok := err.(*mysql.MySQLError); ok {
+	var val *mysql.MySQLError
+	if errors.As(err, &val) {
 		return val.Number
 	}
 	return 0 // not a mysql error
@@ -29,21 +65,5 @@ func ErrorCode(err error) uint16 {
 // CanRetry returns true for every error which can be safely retry.
 // It returns false for all other errors, including nil.
 func CanRetry(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	if err == mysql.ErrInvalidConn || err == driver.ErrBadConn {
-		return true
-	}
-
-	if _, ok := err.(*net.OpError); ok {
-		// Being unable to reach MySQL is a network issue, so we get a net.OpError.
-		// If MySQL is reachable, then we'd get a mysql.* or driver.* error instead.
-		return true
-	}
-
-	code := ErrorCode(err)
-	_, ok := retryableErrors[code]
-	return ok
+	return err != nil && DefaultClassifier.Retryable(err)
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/city-mobil/gobuns/barber"
 	"github.com/city-mobil/gobuns/mysql/mysqlconfig"
+	"github.com/city-mobil/gobuns/retry"
 
 	// register mysql driver
 	_ "github.com/go-sql-driver/mysql"
@@ -114,6 +115,7 @@ type shard struct {
 	slaves []Adapter
 
 	cirulnik        barber.Barber
+	classifier      retry.Classifier
 	failStats       failStats
 	lastUsedReplica uint32
 	connectorType   mysqlconfig.ClusterConnectorType
@@ -130,11 +132,29 @@ func NewShardWithCB(cfg *mysqlconfig.ShardConfig, t mysqlconfig.ClusterConnector
 		cfg.RetryConfig = mysqlconfig.NewDefaultRetryConfig()
 	}
 
+	classifier := cfg.RetryConfig.Classifier
+	if classifier == nil {
+		classifier = NewClassifier(cfg.RetryConfig.RetryableCodes...)
+	}
+
 	return &shard{
 		config:        cfg,
 		connectorType: t,
 		cirulnik:      cb,
+		classifier:    classifier,
+	}
+}
+
+// canRetry returns true if the query failed with err can be retried.
+func (s *shard) canRetry(err error) bool {
+	if err == nil {
+		return false
+	}
+	if s.classifier == nil {
+		return CanRetry(err)
 	}
+
+	return s.classifier.Retryable(err)
 }
 
 // GetMasterConn returns master connection.
@@ -171,7 +191,7 @@ func (s *shard) ExecMasterTolerant(ctx context.Context, query string, args ...in
 		}
 
 		res, err = s.master.ExecContext(ctx, query, args...)
-		if CanRetry(err) {
+		if s.canRetry(err) {
 			continue
 		}
 
@@ -198,7 +218,7 @@ func (s *shard) QueryMasterTolerant(ctx context.Context, query string, args ...i
 		}
 
 		rows, err = s.master.QueryContext(ctx, query, args...)
-		if CanRetry(err) {
+		if s.canRetry(err) {
 			continue
 		}
 
@@ -225,7 +245,7 @@ func (s *shard) QueryRowMasterTolerant(ctx context.Context, query string, args,
 		}
 
 		err = s.master.QueryRowContext(ctx, query, args...).Scan(dest...)
-		if CanRetry(err) {
+		if s.canRetry(err) {
 			continue
 		}
 
@@ -244,6 +264,20 @@ func (s *shard) recordFail(serverID int) {
 	s.failStats.mu.Unlock()
 }
 
+// reportResult updates circuit-breaker state of the slave with the query result.
+func (s *shard) reportResult(connID int, err error) {
+	if s.cirulnik == nil {
+		return
+	}
+
+	if err != nil {
+		s.cirulnik.AddError(connID, time.Now())
+		return
+	}
+
+	s.cirulnik.AddSuccess(connID, time.Now())
+}
+
 func (s *shard) chooseRandom() (Adapter, int) { //nolint:gocritic
 	var idx int
 	now := time.Now()
@@ -256,7 +290,7 @@ func (s *shard) chooseRandom() (Adapter, int) { //nolint:gocritic
 		}
 		// TODO(a.petrukhin): implement disabled replicas.
 		idx = rand.Intn(len(s.slaves)) //nolint:gosec
-		if s.cirulnik.IsAvailable(idx, now) {
+		if s.cirulnik.Allow(idx, now) {
 			break
 		}
 
@@ -279,7 +313,7 @@ func (s *shard) chooseRoundRobin() (Adapter, int) { //nolint:gocritic
 		}
 		next := atomic.AddUint32(&s.lastUsedReplica, 1)
 		next1 = (int(next) - 1) % len(s.slaves)
-		if s.cirulnik.IsAvailable(next1, now) {
+		if s.cirulnik.Allow(next1, now) {
 			break
 		}
 
@@ -309,10 +343,8 @@ func (s *shard) ExecReplica(ctx context.Context, query string, args ...interface
 	conn, connID := s.chooseSlave()
 
 	res, err := conn.ExecContext(ctx, query, args...)
-	if err != nil && s.cirulnik != nil {
-		// NOTE(a.petrukhin): we record all errors.
-		s.cirulnik.AddError(connID, time.Now())
-	}
+	// NOTE(a.petrukhin): we record all errors.
+	s.reportResult(connID, err)
 	return res, err
 }
 
@@ -331,11 +363,9 @@ func (s *shard) ExecReplicaTolerant(ctx context.Context, query string, args ...i
 		}
 
 		res, err = conn.ExecContext(ctx, query, args...)
-		if err != nil && s.cirulnik != nil {
-			s.cirulnik.AddError(connID, time.Now())
-		}
+		s.reportResult(connID, err)
 
-		if CanRetry(err) {
+		if s.canRetry(err) {
 			continue
 		}
 
@@ -349,9 +379,7 @@ func (s *shard) QueryReplica(ctx context.Context, query string, args ...interfac
 	conn, connID := s.chooseSlave()
 
 	res, err := conn.QueryContext(ctx, query, args...)
-	if err != nil && s.cirulnik != nil {
-		s.cirulnik.AddError(connID, time.Now())
-	}
+	s.reportResult(connID, err)
 	return res, err
 }
 
@@ -370,11 +398,9 @@ func (s *shard) QueryReplicaTolerant(ctx context.Context, query string, args ...
 		}
 
 		rows, err = conn.QueryContext(ctx, query, args...)
-		if err != nil && s.cirulnik != nil {
-			s.cirulnik.AddError(connID, time.Now())
-		}
+		s.reportResult(connID, err)
 
-		if CanRetry(err) {
+		if s.canRetry(err) {
 			continue
 		}
 
@@ -409,11 +435,13 @@ func (s *shard) QueryRowReplicaTolerant(ctx context.Context, query string, args,
 		}
 
 		err = conn.QueryRowContext(ctx, query, args...).Scan(dest...)
-		if err != nil && err != sql.ErrNoRows && s.cirulnik != nil {
-			s.cirulnik.AddError(connID, time.Now())
+		if err == sql.ErrNoRows {
+			s.reportResult(connID, nil)
+		} else {
+			s.reportResult(connID, err)
 		}
 
-		if CanRetry(err) {
+		if s.canRetry(err) {
 			continue
 		}
 
@@ -100,6 +100,34 @@ func TestExecMasterTolerant_NotRetryableErr(t *testing.T) {
 	assert.Empty(t, onErr.execN)
 }
 
+func TestExecMasterTolerant_RetryableCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wantErr := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	conn := mock_mysql.NewMockAdapter(ctrl)
+	conn.EXPECT().ExecContext(tCtx, tQuery, gomock.Any()).Times(1).Return(nil, wantErr)
+	conn.EXPECT().ComponentID().Times(1).Return(tAddr)
+	conn.EXPECT().ExecContext(tCtx, tQuery, gomock.Any()).Times(1).Return(nil, nil)
+
+	onErr := &onErrCb{}
+	retryCfg := &mysqlconfig.RetryConfig{
+		Max:            2,
+		ExecOnErr:      onErr.run,
+		RetryableCodes: []uint16{1213},
+	}
+	shardCfg := mysqlconfig.NewWithSingleNode(mysqlconfig.NewDefaultDatabaseConfig(), retryCfg)
+	sh := NewShard(shardCfg, mysqlconfig.ClusterConnectorTypeSQL).(*shard)
+	sh.master = conn
+
+	_, err := sh.ExecMasterTolerant(tCtx, tQuery)
+	assert.NoError(t, err)
+	assert.Equal(t, wantErr, onErr.lastErr)
+	assert.Equal(t, 1, onErr.execN)
+	assert.False(t, CanRetry(wantErr))
+}
+
 func newMockedShard(adapter Adapter) (Shard, *onErrCb) {
 	onErr := &onErrCb{}
 	dbCfg := mysqlconfig.NewDefaultDatabaseConfig()
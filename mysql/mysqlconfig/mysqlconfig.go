@@ -9,6 +9,7 @@ import (
 
 	"github.com/city-mobil/gobuns/barber"
 	"github.com/city-mobil/gobuns/config"
+	"github.com/city-mobil/gobuns/retry"
 )
 
 const (
@@ -146,26 +147,28 @@ func NewDefaultDatabaseConfig() *DatabaseConfig {
 	return d
 }
 
-func NewDatabaseConfig(prefix string) func() *DatabaseConfig {
+func NewDatabaseConfig(prefix string, set ...*config.FlagSet) func() *DatabaseConfig {
+	fs := config.FlagSetOrDefault(set...)
+
 	n := func(opt string) string {
 		return fmt.Sprintf("%s.%s", prefix, opt)
 	}
 
 	var (
-		addr     = config.String(n("addr"), defaultAddr, "MySQL network address")
-		user     = config.String(n("user"), defaultUser, "MySQL username")
-		password = config.String(n("password"), defaultPassword, "MySQL user password")
-		dbName   = config.String(n("dbname"), defaultDBName, "MySQL database name")
-		driver   = config.String(n("driver"), defaultSQLDriver, "MySQL database driver")
-		timezone = config.String(n("timezone"), defaultTimeZone, "MySQL Database Timezone")
-
-		timeout      = config.Duration(n("timeout"), defaultTimeout, "MySQL connection timeout")
-		readTimeout  = config.Duration(n("read_timeout"), defaultReadTimeout, "MySQL I/O read timeout")
-		writeTimeout = config.Duration(n("write_timeout"), defaultWriteTimeout, "MySQL I/O write timeout")
-
-		maxOpenConnections    = config.Int(n("pool.max_open_connections"), defaultMaxOpenConns, "The maximum number of open connections to the database")
-		maxIdleConnections    = config.Int(n("pool.max_idle_connections"), defaultMaxIdleConns, "the maximum number of connections in the idle connection pool")
-		connectionMaxLifetime = config.Duration(n("pool.max_life_time"), defaultConnMaxLifetime, "The maximum amount of time a connection may be reused")
+		addr     = fs.String(n("addr"), defaultAddr, "MySQL network address")
+		user     = fs.String(n("user"), defaultUser, "MySQL username")
+		password = fs.Secret(n("password"), defaultPassword, "MySQL user password")
+		dbName   = fs.String(n("dbname"), defaultDBName, "MySQL database name")
+		driver   = fs.String(n("driver"), defaultSQLDriver, "MySQL database driver")
+		timezone = fs.String(n("timezone"), defaultTimeZone, "MySQL Database Timezone")
+
+		timeout      = fs.Duration(n("timeout"), defaultTimeout, "MySQL connection timeout")
+		readTimeout  = fs.Duration(n("read_timeout"), defaultReadTimeout, "MySQL I/O read timeout")
+		writeTimeout = fs.Duration(n("write_timeout"), defaultWriteTimeout, "MySQL I/O write timeout")
+
+		maxOpenConnections    = fs.Int(n("pool.max_open_connections"), defaultMaxOpenConns, "The maximum number of open connections to the database")
+		maxIdleConnections    = fs.Int(n("pool.max_idle_connections"), defaultMaxIdleConns, "the maximum number of connections in the idle connection pool")
+		connectionMaxLifetime = fs.Duration(n("pool.max_life_time"), defaultConnMaxLifetime, "The maximum amount of time a connection may be reused")
 	)
 
 	return func() *DatabaseConfig {
@@ -263,6 +266,13 @@ type RetryConfig struct {
 	Timeout time.Duration
 	// ExecOnErr executes before query retry.
 	ExecOnErr func(host string, err error)
+	// RetryableCodes is a list of MySQL server error codes which are retryable
+	// in addition to the default ones, e.g. 1213 (ER_LOCK_DEADLOCK).
+	RetryableCodes []uint16
+	// Classifier decides whether an error can be retried.
+	//
+	// If set, RetryableCodes are ignored.
+	Classifier retry.Classifier
 }
 
 func NewDefaultRetryConfig() *RetryConfig {
@@ -273,21 +283,30 @@ func NewDefaultRetryConfig() *RetryConfig {
 	}
 }
 
-func NewRetryConfig(prefix string) func() *RetryConfig {
+func NewRetryConfig(prefix string, set ...*config.FlagSet) func() *RetryConfig {
+	fs := config.FlagSetOrDefault(set...)
+
 	n := func(opt string) string {
 		return fmt.Sprintf("%s.%s", prefix, opt)
 	}
 
 	var (
-		retryMax     = config.Int(n("max"), defaultMaxRetries, "The maximum number of attempts before give up")
-		retryTimeout = config.Duration(n("timeout"), defaultRetryTimeout, "Wait given period before trying another attempt")
+		retryMax     = fs.Int(n("max"), defaultMaxRetries, "The maximum number of attempts before give up")
+		retryTimeout = fs.Duration(n("timeout"), defaultRetryTimeout, "Wait given period before trying another attempt")
+		retryCodes   = fs.UintSlice(n("retryable_codes"), nil, "MySQL error codes retryable in addition to the default ones")
 	)
 
 	return func() *RetryConfig {
+		codes := make([]uint16, 0, len(*retryCodes))
+		for _, code := range *retryCodes {
+			codes = append(codes, uint16(code))
+		}
+
 		return &RetryConfig{
-			Max:       *retryMax,
-			Timeout:   *retryTimeout,
-			ExecOnErr: nil,
+			Max:            *retryMax,
+			Timeout:        *retryTimeout,
+			ExecOnErr:      nil,
+			RetryableCodes: codes,
 		}
 	}
 }
@@ -24,6 +24,31 @@ func InstrumentWithRegisterer(reg prometheus.Registerer) InstrumentOption {
 	}
 }
 
+// Registerer returns the registerer set by InstrumentWithRegisterer option
+// or prometheus.DefaultRegisterer.
+func Registerer(opts ...InstrumentOption) prometheus.Registerer {
+	inst := newInstrument()
+	for _, opt := range opts {
+		opt(inst)
+	}
+
+	return inst.registry
+}
+
+// register registers all the given collectors or none of them.
+func register(reg prometheus.Registerer, cs ...prometheus.Collector) error {
+	for i, c := range cs {
+		if err := reg.Register(c); err != nil {
+			for _, prev := range cs[:i] {
+				reg.Unregister(prev)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 type instrument struct {
 	registry     prometheus.Registerer
 	pathNameFunc func(r *http.Request) string
@@ -99,7 +124,9 @@ func InstrumentRoundTripper(name string, next http.RoundTripper, opts ...Instrum
 		[]string{"method", "path"},
 	)
 
-	inst.registry.MustRegister(counter, tlsLatencyVec, dnsLatencyVec, histVec, inFlightGauge)
+	if err := register(inst.registry, counter, tlsLatencyVec, dnsLatencyVec, histVec, inFlightGauge); err != nil {
+		panic(err)
+	}
 
 	// Define functions for the available httptrace.ClientTrace hook
 	// functions that we want to instrument.
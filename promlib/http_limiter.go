@@ -0,0 +1,61 @@
+package promlib
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LimiterMetrics collects metrics of the client-side rate limiter.
+type LimiterMetrics struct {
+	wait     *prometheus.HistogramVec
+	rejected *prometheus.CounterVec
+}
+
+// NewLimiterMetrics creates and registers rate limiter metrics of the HTTP client
+// with the given name. Only InstrumentWithRegisterer option is applied.
+//
+// Metrics must be created only once for a given name and registerer,
+// otherwise the registration error is returned.
+func NewLimiterMetrics(name string, opts ...InstrumentOption) (*LimiterMetrics, error) {
+	inst := newInstrument()
+	for _, opt := range opts {
+		opt(inst)
+	}
+
+	wait := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    name + "_rate_limiter_wait_seconds",
+			Help:    "A histogram of time requests waited for the rate limiter.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+		[]string{"host"},
+	)
+
+	rejected := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: name + "_rate_limiter_rejected_total",
+			Help: "A counter for requests rejected by the rate limiter.",
+		},
+		[]string{"host"},
+	)
+
+	if err := register(inst.registry, wait, rejected); err != nil {
+		return nil, err
+	}
+
+	return &LimiterMetrics{
+		wait:     wait,
+		rejected: rejected,
+	}, nil
+}
+
+// ObserveWait observes the time the request to the host waited for the rate limiter.
+func (m *LimiterMetrics) ObserveWait(host string, d time.Duration) {
+	m.wait.WithLabelValues(host).Observe(d.Seconds())
+}
+
+// IncRejected increments the counter of requests to the host rejected by the rate limiter.
+func (m *LimiterMetrics) IncRejected(host string) {
+	m.rejected.WithLabelValues(host).Inc()
+}
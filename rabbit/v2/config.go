@@ -33,17 +33,19 @@ func (c *Config) GetURI() string {
 	return fmt.Sprintf("amqp://%s:%s@%s/", c.Login, c.Password, c.Addr)
 }
 
-func NewConnectorConfig(prefix string) func() *Config {
+func NewConnectorConfig(prefix string, set ...*config.FlagSet) func() *Config {
+	fs := config.FlagSetOrDefault(set...)
+
 	n := func(opt string) string {
 		return fmt.Sprintf("%s.%s", prefix, opt)
 	}
 	var (
-		addr           = config.String(n("addr"), defURI, "RabbitMQ address")
-		login          = config.String(n("login"), defURI, "RabbitMQ login")
-		password       = config.String(n("password"), defURI, "RabbitMQ password")
-		reconnectDelay = config.Duration(n("reconnect_delay"), defReconnectDelay, "Sleep between reconnections")
-		connectTimeout = config.Duration(n("connect_timeout"), defConnectTimeout, "Connection timeout")
-		heartbeat      = config.Duration(n("heartbeat"), defHeartbeat, "Server heartbeat interval")
+		addr           = fs.String(n("addr"), defURI, "RabbitMQ address")
+		login          = fs.String(n("login"), defURI, "RabbitMQ login")
+		password       = fs.Secret(n("password"), defURI, "RabbitMQ password")
+		reconnectDelay = fs.Duration(n("reconnect_delay"), defReconnectDelay, "Sleep between reconnections")
+		connectTimeout = fs.Duration(n("connect_timeout"), defConnectTimeout, "Connection timeout")
+		heartbeat      = fs.Duration(n("heartbeat"), defHeartbeat, "Server heartbeat interval")
 	)
 
 	return func() *Config {
@@ -81,18 +83,20 @@ func (c *ConsumerCfg) withDefaults() {
 	}
 }
 
-func NewConsumerConfig(prefix string) func() *ConsumerCfg {
+func NewConsumerConfig(prefix string, set ...*config.FlagSet) func() *ConsumerCfg {
+	fs := config.FlagSetOrDefault(set...)
+
 	n := func(opt string) string {
 		return fmt.Sprintf("%s.%s", prefix, opt)
 	}
 	var (
-		queue          = config.String(n("queue_name"), defName, "Consuming queue name")
-		consumerName   = config.String(n("consumer"), defName, "Consumer name")
-		consumersCount = config.Int64(n("consumers_count"), defConsumerCount, "Count of consumer workers")
-		heartbeat      = config.Duration(n("heartbeat"), defConsumerHeartbeat, "Interval between checking count of consumers")
-		autoAck        = config.Bool(n("auto_ack"), false, "Server will acknowledge deliveries to consumer prior to writing delivery to network")
-		exclusive      = config.Bool(n("exclusive"), false, "Server will ensure this is the sole consumer from this queue")
-		noWait         = config.Bool(n("no_wait"), false, "Don't wait for server to confirm request and immediately begin deliveries")
+		queue          = fs.String(n("queue_name"), defName, "Consuming queue name")
+		consumerName   = fs.String(n("consumer"), defName, "Consumer name")
+		consumersCount = fs.Int64(n("consumers_count"), defConsumerCount, "Count of consumer workers")
+		heartbeat      = fs.Duration(n("heartbeat"), defConsumerHeartbeat, "Interval between checking count of consumers")
+		autoAck        = fs.Bool(n("auto_ack"), false, "Server will acknowledge deliveries to consumer prior to writing delivery to network")
+		exclusive      = fs.Bool(n("exclusive"), false, "Server will ensure this is the sole consumer from this queue")
+		noWait         = fs.Bool(n("no_wait"), false, "Don't wait for server to confirm request and immediately begin deliveries")
 	)
 
 	return func() *ConsumerCfg {
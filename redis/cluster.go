@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -28,13 +29,18 @@ type cluster struct { //nolint:golint
 }
 
 func (c *cluster) handleError(err error) {
-	if err != nil && err != ErrorRedisUnavailable {
+	switch {
+	case err == ErrorRedisUnavailable, errors.Is(err, context.Canceled):
+		return
+	case isFailure(err):
 		c.cb.AddError(cbClusterServerID, time.Now())
+	default:
+		c.cb.AddSuccess(cbClusterServerID, time.Now())
 	}
 }
 
 func (c *cluster) getClient() goredis.Cmdable {
-	if c.cb.IsAvailable(cbClusterServerID, time.Now()) {
+	if c.cb.Allow(cbClusterServerID, time.Now()) {
 		return c.client
 	}
 	return c.fallback
@@ -6,12 +6,21 @@ import (
 	"time"
 
 	goredis "github.com/go-redis/redis/v8"
+
+	"github.com/city-mobil/gobuns/barber"
 )
 
 var (
 	ErrorRedisUnavailable = errors.New("redis unavailable")
 )
 
+// isFailure reports whether the error must be counted by the circuit-breaker.
+//
+// redis.Nil means that the key does not exist, so the node is alive.
+func isFailure(err error) bool {
+	return err != goredis.Nil && barber.DefaultFailurePredicate(err)
+}
+
 type fallback struct{}
 
 func newFallback() goredis.Cmdable {
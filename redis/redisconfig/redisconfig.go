@@ -71,9 +71,9 @@ type redisConfig struct {
 
 const configPrefix = "redis"
 
-func newRedisConfig() func() *redisConfig {
+func newRedisConfig(prefix string, fs *config.FlagSet) func() *redisConfig {
 	prefixBuilder := strings.Builder{}
-	prefixBuilder.WriteString(configPrefix)
+	prefixBuilder.WriteString(prefix)
 	o := func(opt string) string {
 		optBuilder := strings.Builder{}
 		optBuilder.WriteString(prefixBuilder.String())
@@ -83,32 +83,32 @@ func newRedisConfig() func() *redisConfig {
 	}
 
 	var (
-		addr     = config.StringSlice(o("addr"), nil, "Redis hostname/hostnames")
-		username = config.String(o("username"), "", "Redis username")
-		password = config.String(o("password"), "", "Redis user password")
-
-		dialTimeout  = config.Duration(o("dial-timeout"), defaultDialTimeout, "Redis dial timeout")
-		readTimeout  = config.Duration(o("read-timeout"), defaultReadTimeout, "Redis read timeout")
-		writeTimeout = config.Duration(o("write-timeout"), defaultWriteTimeout, "Redis write timeout")
-
-		maxRetries      = config.Int(o("max-retries"), defaultMaxRetries, "Redis max retries")
-		minRetryBackoff = config.Duration(o("min-retry-backoff"), defaultMinRetryBackoff, "Redis min retry backoff")
-		maxRetryBackoff = config.Duration(o("max-retry-backoff"), defaultMaxRetryBackoff, "Redis max retry backoff")
-
-		poolSize           = config.Int(o("pool-size"), defaultPoolSize, "Redis pool size")
-		minIdleConns       = config.Int(o("min-idle-conns"), defaultMinIdleConns, "Redis min idle conns")
-		poolTimeout        = config.Duration(o("pool-timeout"), defaultPoolTimeout, "Redis pool timeout")
-		idleTimeout        = config.Duration(o("idle-timeout"), defaultIdleTimeout, "Redis idle timeout")
-		idleCheckFrequency = config.Duration(o("idle-check-frequency"), defaultIdleCheckFrequency, "Redis idle check frequency")
-		maxConnAge         = config.Duration(o("max-conn-age"), defaultMaxConnAge, "Redis max conn age")
-
-		tracerWithHook = config.Bool(o("tracer.with-hook"), defaultTracerWithHook, "Redis tracer enabler")
+		addr     = fs.StringSlice(o("addr"), nil, "Redis hostname/hostnames")
+		username = fs.String(o("username"), "", "Redis username")
+		password = fs.Secret(o("password"), "", "Redis user password")
+
+		dialTimeout  = fs.Duration(o("dial-timeout"), defaultDialTimeout, "Redis dial timeout")
+		readTimeout  = fs.Duration(o("read-timeout"), defaultReadTimeout, "Redis read timeout")
+		writeTimeout = fs.Duration(o("write-timeout"), defaultWriteTimeout, "Redis write timeout")
+
+		maxRetries      = fs.Int(o("max-retries"), defaultMaxRetries, "Redis max retries")
+		minRetryBackoff = fs.Duration(o("min-retry-backoff"), defaultMinRetryBackoff, "Redis min retry backoff")
+		maxRetryBackoff = fs.Duration(o("max-retry-backoff"), defaultMaxRetryBackoff, "Redis max retry backoff")
+
+		poolSize           = fs.Int(o("pool-size"), defaultPoolSize, "Redis pool size")
+		minIdleConns       = fs.Int(o("min-idle-conns"), defaultMinIdleConns, "Redis min idle conns")
+		poolTimeout        = fs.Duration(o("pool-timeout"), defaultPoolTimeout, "Redis pool timeout")
+		idleTimeout        = fs.Duration(o("idle-timeout"), defaultIdleTimeout, "Redis idle timeout")
+		idleCheckFrequency = fs.Duration(o("idle-check-frequency"), defaultIdleCheckFrequency, "Redis idle check frequency")
+		maxConnAge         = fs.Duration(o("max-conn-age"), defaultMaxConnAge, "Redis max conn age")
+
+		tracerWithHook = fs.Bool(o("tracer.with-hook"), defaultTracerWithHook, "Redis tracer enabler")
 	)
 
 	cbPrefixBuilder := strings.Builder{}
 	cbPrefixBuilder.WriteString(prefixBuilder.String())
 	cbPrefixBuilder.WriteString(".barber")
-	cbCfgFn := barber.NewConfig(cbPrefixBuilder.String())
+	cbCfgFn := barber.NewConfig(cbPrefixBuilder.String(), fs)
 
 	return func() *redisConfig {
 		return &redisConfig{
@@ -146,19 +146,29 @@ type RedisClusterConfig struct {
 }
 
 // NewClusterConfig returns cluster configuration with default parameters
-func NewClusterConfig() func() (*RedisClusterConfig, error) {
+func NewClusterConfig(set ...*config.FlagSet) func() (*RedisClusterConfig, error) {
+	return NewClusterConfigWithPrefix(configPrefix, set...)
+}
+
+// NewClusterConfigWithPrefix returns cluster configuration having the given prefix
+// instead of "redis".
+//
+// It can be used to declare several clusters with config.SectionErr.
+func NewClusterConfigWithPrefix(prefix string, set ...*config.FlagSet) func() (*RedisClusterConfig, error) {
+	fs := config.FlagSetOrDefault(set...)
+
 	o := func(opt string) string {
-		return fmt.Sprintf("%s.%s", "redis.cluster", opt)
+		return fmt.Sprintf("%s.cluster.%s", prefix, opt)
 	}
 
 	var (
-		maxRedirects   = config.Int(o("max-redirects"), defaultMaxRedirects, "Redis cluster max redirects (max moved)")
-		readOnly       = config.Bool(o("readonly"), defaultReadOnly, "Redis cluster readonly")
-		routeByLatency = config.Bool(o("route-by-latency"), defaultRouteByLatency, "Redis cluster route by latency")
-		routeRandomly  = config.Bool(o("route-randomly"), defaultRouteRandomly, "Redis cluster route randomly")
+		maxRedirects   = fs.Int(o("max-redirects"), defaultMaxRedirects, "Redis cluster max redirects (max moved)")
+		readOnly       = fs.Bool(o("readonly"), defaultReadOnly, "Redis cluster readonly")
+		routeByLatency = fs.Bool(o("route-by-latency"), defaultRouteByLatency, "Redis cluster route by latency")
+		routeRandomly  = fs.Bool(o("route-randomly"), defaultRouteRandomly, "Redis cluster route randomly")
 	)
 
-	commonConfig := newRedisConfig()
+	commonConfig := newRedisConfig(prefix, fs)
 	return func() (*RedisClusterConfig, error) {
 		conf := commonConfig()
 
@@ -200,17 +210,27 @@ type RedisStandaloneConfig struct {
 }
 
 // NewStandaloneConfig returns
-func NewStandaloneConfig() func() (*RedisStandaloneConfig, error) {
+func NewStandaloneConfig(set ...*config.FlagSet) func() (*RedisStandaloneConfig, error) {
+	return NewStandaloneConfigWithPrefix(configPrefix, set...)
+}
+
+// NewStandaloneConfigWithPrefix returns standalone configuration having the given prefix
+// instead of "redis".
+//
+// It can be used to declare several instances with config.SectionErr.
+func NewStandaloneConfigWithPrefix(prefix string, set ...*config.FlagSet) func() (*RedisStandaloneConfig, error) {
+	fs := config.FlagSetOrDefault(set...)
+
 	o := func(opt string) string {
-		return fmt.Sprintf("%s.%s", "redis.standalone", opt)
+		return fmt.Sprintf("%s.standalone.%s", prefix, opt)
 	}
 
 	var (
-		replicas = config.StringSlice(o("replicas"), nil, "Redis replicas")
-		db       = config.Int(o("db"), defaultDatabase, "Redis database")
+		replicas = fs.StringSlice(o("replicas"), nil, "Redis replicas")
+		db       = fs.Int(o("db"), defaultDatabase, "Redis database")
 	)
 
-	commonConfig := newRedisConfig()
+	commonConfig := newRedisConfig(prefix, fs)
 	return func() (*RedisStandaloneConfig, error) {
 		conf := commonConfig()
 
@@ -46,7 +46,7 @@ func (s *replicas) getNode() (*node, error) {
 	for i := 0; i < s.itemsCnt; i++ {
 		next := atomic.AddUint32(&s.lastUsedReplica, 1)
 		next1 = (int(next) - 1) % s.itemsCnt
-		if s.cb.IsAvailable(next1, now) {
+		if s.cb.Allow(next1, now) {
 			return s.items[next1], nil
 		}
 	}
@@ -86,8 +86,13 @@ func (s *standaloneConn) handleError(index int, err error) {
 		return
 	}
 
-	if err != nil && err != ErrorRedisUnavailable {
+	switch {
+	case err == ErrorRedisUnavailable, errors.Is(err, context.Canceled):
+		return
+	case isFailure(err):
 		s.cb.AddError(index, time.Now())
+	default:
+		s.cb.AddSuccess(index, time.Now())
 	}
 }
 
@@ -100,7 +105,7 @@ func (s *standaloneConn) getNode() *node {
 }
 
 func (s *standaloneConn) getMaster() *node {
-	if s.cb.IsAvailable(cbMasterConnID, time.Now()) {
+	if s.cb.Allow(cbMasterConnID, time.Now()) {
 		return s.master
 	}
 	return s.fallback
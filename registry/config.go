@@ -17,9 +17,15 @@ type Config struct {
 	QueryTimeout time.Duration
 	// RetryConfig is a configuration for retry policy.
 	RetryConfig *retry.Config
+	// Classifier decides whether a consul error can be retried.
+	//
+	// By default: DefaultClassifier.
+	Classifier retry.Classifier
 }
 
-func NewConfig(prefix string) func() Config {
+func NewConfig(prefix string, set ...*config.FlagSet) func() Config {
+	fs := config.FlagSetOrDefault(set...)
+
 	if prefix != "" {
 		prefix += ".registry."
 	} else {
@@ -27,9 +33,9 @@ func NewConfig(prefix string) func() Config {
 	}
 
 	var (
-		addr         = config.String(prefix+"addr", defaultAddr, "consul agent address")
-		queryTimeout = config.Duration(prefix+"query_timeout", defaultTimeout, "timeout for KV request")
-		retryCfgFn   = retry.GetRetryConfig(prefix + "retries")
+		addr         = fs.String(prefix+"addr", defaultAddr, "consul agent address")
+		queryTimeout = fs.Duration(prefix+"query_timeout", defaultTimeout, "timeout for KV request")
+		retryCfgFn   = retry.GetRetryConfig(prefix+"retries", fs)
 	)
 
 	return func() Config {
@@ -49,6 +49,9 @@ type client struct {
 	componentID   string
 }
 
+// DefaultClassifier classifies consul errors with consulapi.IsRetryableError.
+var DefaultClassifier retry.Classifier = retry.ClassifierFunc(consulapi.IsRetryableError)
+
 func NewClient(config Config) (Client, error) {
 	consulCfg := consulapi.DefaultConfig()
 	consulCfg.Address = config.Addr
@@ -58,10 +61,18 @@ func NewClient(config Config) (Client, error) {
 		return nil, err
 	}
 
+	classifier := config.Classifier
+	if classifier == nil {
+		classifier = DefaultClassifier
+	}
+
+	retrier := retry.New(config.RetryConfig)
+	retrier.SetClassifier(classifier)
+
 	return &client{
 		consulClient: c,
 		queryTimeout: config.QueryTimeout,
-		retrier:      retry.New(config.RetryConfig),
+		retrier:      retrier,
 
 		name:          defaultName,
 		componentType: defaultType,
@@ -135,14 +146,10 @@ func (c *client) SetComponentID(componentID string) {
 }
 
 func (c *client) getWithRetries(ctx context.Context, key string) (string, error) {
-	var (
-		resp *consulapi.KVPair
-		span opentracing.Span
-		err  error
-	)
+	var span opentracing.Span
 	rootSpan := opentracing.SpanFromContext(ctx)
 
-	action := func() error {
+	action := func(ctx context.Context, _ uint) (*consulapi.KVPair, error) {
 		if rootSpan != nil {
 			if span == nil {
 				span, _ = opentracing.StartSpanFromContextWithTracer(ctx, rootSpan.Tracer(), "consul/get")
@@ -154,7 +161,7 @@ func (c *client) getWithRetries(ctx context.Context, key string) (string, error)
 			ext.SpanKindRPCClient.Set(span)
 			ext.PeerHostname.Set(span, c.componentID)
 		}
-		resp, err = c.get(ctx, key)
+		resp, err := c.get(ctx, key)
 		if span != nil {
 			if err != nil {
 				ext.Error.Set(span, true)
@@ -163,21 +170,13 @@ func (c *client) getWithRetries(ctx context.Context, key string) (string, error)
 			span.Finish()
 		}
 		if err != nil {
-			if consulapi.IsRetryableError(err) {
-				return err
-			}
-
-			return retry.Unrecoverable(err)
+			return nil, err
 		}
 
-		return nil
-	}
-
-	onRetry := func(n uint, err error) {
-		// nothing to do
+		return resp, nil
 	}
 
-	err = c.retrier.Do(ctx, action, onRetry)
+	resp, err := retry.DoValue(ctx, c.retrier, action)
 	if err != nil {
 		return "", err
 	}
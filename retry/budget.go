@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrBudgetExhausted is matched by BudgetExhaustedError with errors.Is.
+	ErrBudgetExhausted = errors.New("retry budget exhausted")
+)
+
+// BudgetExhaustedError is returned when the action could be retried,
+// but the retry budget has no tokens left.
+type BudgetExhaustedError struct {
+	// Attempts is an amount of made attempts.
+	Attempts uint
+	// Err is an error of the last attempt.
+	Err error
+}
+
+func (e *BudgetExhaustedError) Error() string {
+	return fmt.Sprintf("%s after %d attempts: %v", ErrBudgetExhausted, e.Attempts, e.Err)
+}
+
+func (e *BudgetExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+func (e *BudgetExhaustedError) Is(target error) bool {
+	return target == ErrBudgetExhausted
+}
+
+// Budget is a token bucket which limits retries to a percent of calls.
+//
+// Each call deposits Percent/100 tokens, each retry withdraws one token.
+// That prevents retries from amplifying an outage of the downstream service.
+type Budget struct {
+	mu        sync.Mutex
+	tokens    float64
+	deposit   float64
+	maxTokens float64
+}
+
+// NewBudget creates new retry budget. The budget is full after creation.
+func NewBudget(cfg *BudgetConfig) *Budget {
+	return &Budget{
+		tokens:    cfg.MaxTokens,
+		deposit:   cfg.Percent / 100,
+		maxTokens: cfg.MaxTokens,
+	}
+}
+
+// onCall deposits tokens for a single call.
+func (b *Budget) onCall() {
+	b.mu.Lock()
+	b.tokens += b.deposit
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+	b.mu.Unlock()
+}
+
+// tryRetry withdraws a token for a single retry. It returns false if there are no tokens left.
+func (b *Budget) tryRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -3,7 +3,6 @@ package retry
 import (
 	"time"
 
-	"github.com/avast/retry-go"
 	"github.com/city-mobil/gobuns/config"
 )
 
@@ -18,32 +17,28 @@ const (
 	BackOff WaitStrategy = "backoff"
 	// Combine combines BackOff and Random strategies.
 	Combine WaitStrategy = "combine"
+	// FullJitter picks a random duration up to the BackOff one.
+	FullJitter WaitStrategy = "full_jitter"
+	// DecorrelatedJitter picks a random duration between BaseWait
+	// and the tripled previous duration.
+	DecorrelatedJitter WaitStrategy = "decorrelated_jitter"
 )
 
-func getDelayFunc(s WaitStrategy) retry.DelayTypeFunc {
-	switch s {
-	case BackOff:
-		return retry.BackOffDelay
-	case Random:
-		return retry.RandomDelay
-	case Combine:
-		return retry.CombineDelay(retry.BackOffDelay, retry.RandomDelay)
-	default:
-		return retry.FixedDelay
-	}
-}
-
 const (
-	DefaultWaitType  = string(Fixed)
-	DefaultAttempts  = 5
-	DefaultBaseWait  = 10 * time.Millisecond
-	DefaultMaxWait   = 10 * time.Millisecond
-	DefaultMaxJitter = 10 * time.Millisecond
+	DefaultWaitType   = string(Fixed)
+	DefaultAttempts   = 5
+	DefaultBaseWait   = 10 * time.Millisecond
+	DefaultMaxWait    = 10 * time.Millisecond
+	DefaultMaxJitter  = 10 * time.Millisecond
+	DefaultMultiplier = 2.0
+
+	DefaultBudgetMaxTokens = 10
 )
 
 // WaitConfig is a configuration to calc wait duration.
 type WaitConfig struct {
-	// BaseWait is a base duration for Fixed, BackOff strategies.
+	// BaseWait is a base duration for Fixed, BackOff, FullJitter
+	// and DecorrelatedJitter strategies.
 	BaseWait time.Duration
 
 	// MaxJitter sets the maximum random jitter for Random strategy.
@@ -52,65 +47,129 @@ type WaitConfig struct {
 	// MaxWait is a maximum possible duration for any strategy.
 	MaxWait time.Duration
 
+	// Multiplier is a growth factor of the BackOff duration
+	// for BackOff, Combine and FullJitter strategies.
+	//
+	// By default: 2.
+	Multiplier float64
+
 	// WaitType is a wait strategy.
 	//
 	// By default: Fixed.
 	// Other options:
 	//   - "backoff": BackOff increases delay between consecutive retries,
 	//   - "random": Random picks a random delay up to MaxJitter,
-	//   - "combine": Use BackOff and Random together.
+	//   - "combine": Use BackOff and Random together,
+	//   - "full_jitter": FullJitter picks a random delay up to the BackOff one,
+	//   - "decorrelated_jitter": DecorrelatedJitter picks a random delay
+	//     between BaseWait and the tripled previous delay.
 	WaitType WaitStrategy
 }
 
+// BudgetConfig is a retry budget configuration.
+type BudgetConfig struct {
+	// Percent is a maximum percent of retries relative to calls.
+	//
+	// Zero disables the budget.
+	Percent float64
+
+	// MaxTokens is a maximum amount of retries which can be accumulated
+	// in the budget.
+	MaxTokens float64
+}
+
 // Config is a retry configuration.
 type Config struct {
 	WaitConfig
 
 	// MaxAttempts is a maximum attempts to make an action.
 	MaxAttempts int
+
+	// AttemptTimeout is a configuration to calc timeout of the each attempt.
+	//
+	// Timeout of an attempt is calculated in the same way as wait duration.
+	// Zero timeout means that the attempt has no own deadline.
+	// Zero MaxWait means that the timeout is not limited.
+	// Nil disables per-attempt timeouts.
+	AttemptTimeout *WaitConfig
+
+	// Budget is a retry budget configuration shared by all calls of the Retrier.
+	//
+	// Nil disables the budget.
+	Budget *BudgetConfig
 }
 
-func GetRetryConfig(prefix string) func() *Config {
+const waitTypeUsage = "wait strategy: backoff, random, combine, full_jitter, decorrelated_jitter, fixed (default)"
+
+func GetRetryConfig(prefix string, set ...*config.FlagSet) func() *Config {
+	fs := config.FlagSetOrDefault(set...)
+
 	prefix = config.SanitizePrefix(prefix)
 	var (
-		maxAttempts = config.Int(prefix+"max_attempts", DefaultAttempts, "max action attempts")
-		baseWait    = config.Duration(prefix+"base_wait", DefaultBaseWait, "base wait duration for Fixed or BackOff strategies")
-		maxWait     = config.Duration(prefix+"max_wait", DefaultMaxWait, "maximum wait duration between retries")
-		maxJitter   = config.Duration(prefix+"max_jitter", DefaultMaxJitter, "maximum random jitter for Random strategy")
-		waitType    = config.String(prefix+"wait_type", DefaultWaitType, "wait strategy: backoff, random, combine, fixed (default)")
+		maxAttempts = fs.Int(prefix+"max_attempts", DefaultAttempts, "max action attempts")
+		baseWait    = fs.Duration(prefix+"base_wait", DefaultBaseWait, "base wait duration for Fixed or BackOff strategies")
+		maxWait     = fs.Duration(prefix+"max_wait", DefaultMaxWait, "maximum wait duration between retries")
+		maxJitter   = fs.Duration(prefix+"max_jitter", DefaultMaxJitter, "maximum random jitter for Random strategy")
+		multiplier  = fs.Float64(prefix+"backoff_multiplier", DefaultMultiplier, "growth factor of the BackOff wait duration")
+		waitType    = fs.String(prefix+"wait_type", DefaultWaitType, waitTypeUsage)
+
+		attemptTimeoutFn = getWaitConfig(prefix+"attempt_timeout", 0, 0, fs)
+		budgetPercent    = fs.Float64(prefix+"budget.percent", 0, "maximum percent of retries relative to calls, 0 disables the budget")
+		budgetMaxTokens  = fs.Float64(prefix+"budget.max_tokens", DefaultBudgetMaxTokens, "maximum amount of retries accumulated in the budget")
 	)
 
 	return func() *Config {
 		conf := &Config{
 			WaitConfig: WaitConfig{
-				BaseWait:  *baseWait,
-				MaxJitter: *maxJitter,
-				MaxWait:   *maxWait,
-				WaitType:  WaitStrategy(*waitType),
+				BaseWait:   *baseWait,
+				MaxJitter:  *maxJitter,
+				MaxWait:    *maxWait,
+				Multiplier: *multiplier,
+				WaitType:   WaitStrategy(*waitType),
 			},
-			MaxAttempts: *maxAttempts,
+			MaxAttempts:    *maxAttempts,
+			AttemptTimeout: attemptTimeoutFn(),
+		}
+		if *budgetPercent > 0 {
+			conf.Budget = &BudgetConfig{
+				Percent:   *budgetPercent,
+				MaxTokens: *budgetMaxTokens,
+			}
 		}
 		return ConfigWithDefaults(conf, DefaultBaseWait)
 	}
 }
 
-func GetWaitConfig(prefix string, defBaseWait time.Duration) func() *WaitConfig {
+func GetWaitConfig(prefix string, defBaseWait time.Duration, set ...*config.FlagSet) func() *WaitConfig {
+	waitCfgFn := getWaitConfig(prefix, defBaseWait, DefaultMaxWait, set...)
+
+	return func() *WaitConfig {
+		return WaitConfigWithDefaults(waitCfgFn(), defBaseWait)
+	}
+}
+
+// getWaitConfig registers the wait config flags with the given defaults
+// and returns the config without applying WaitConfigWithDefaults.
+func getWaitConfig(prefix string, defBaseWait, defMaxWait time.Duration, set ...*config.FlagSet) func() *WaitConfig {
+	fs := config.FlagSetOrDefault(set...)
+
 	prefix = config.SanitizePrefix(prefix)
 	var (
-		baseWait  = config.Duration(prefix+"base_wait", defBaseWait, "base wait duration for Fixed or BackOff strategies")
-		maxWait   = config.Duration(prefix+"max_wait", DefaultMaxWait, "maximum wait duration between retries")
-		maxJitter = config.Duration(prefix+"max_jitter", DefaultMaxJitter, "maximum random jitter for Random strategy")
-		waitType  = config.String(prefix+"wait_type", DefaultWaitType, "wait strategy: backoff, random, combine, fixed (default)")
+		baseWait   = fs.Duration(prefix+"base_wait", defBaseWait, "base wait duration for Fixed or BackOff strategies")
+		maxWait    = fs.Duration(prefix+"max_wait", defMaxWait, "maximum wait duration between retries")
+		maxJitter  = fs.Duration(prefix+"max_jitter", DefaultMaxJitter, "maximum random jitter for Random strategy")
+		multiplier = fs.Float64(prefix+"backoff_multiplier", DefaultMultiplier, "growth factor of the BackOff wait duration")
+		waitType   = fs.String(prefix+"wait_type", DefaultWaitType, waitTypeUsage)
 	)
 
 	return func() *WaitConfig {
-		conf := &WaitConfig{
-			BaseWait:  *baseWait,
-			MaxJitter: *maxJitter,
-			MaxWait:   *maxWait,
-			WaitType:  WaitStrategy(*waitType),
+		return &WaitConfig{
+			BaseWait:   *baseWait,
+			MaxJitter:  *maxJitter,
+			MaxWait:    *maxWait,
+			Multiplier: *multiplier,
+			WaitType:   WaitStrategy(*waitType),
 		}
-		return WaitConfigWithDefaults(conf, defBaseWait)
 	}
 }
 
@@ -139,16 +198,25 @@ func ConfigWithDefaults(cfg *Config, defBaseWait time.Duration) *Config {
 	waitCfg := WaitConfigWithDefaults(&cfg.WaitConfig, defBaseWait)
 	cfg.WaitConfig = *waitCfg
 
+	if cfg.AttemptTimeout != nil {
+		cfg.AttemptTimeout = attemptTimeoutWithDefaults(cfg.AttemptTimeout)
+	}
+
+	if cfg.Budget != nil && cfg.Budget.MaxTokens < 1 {
+		cfg.Budget.MaxTokens = DefaultBudgetMaxTokens
+	}
+
 	return cfg
 }
 
 func WaitConfigWithDefaults(cfg *WaitConfig, defBaseWait time.Duration) *WaitConfig {
 	if cfg == nil {
 		cfg = &WaitConfig{
-			BaseWait:  defBaseWait,
-			MaxJitter: DefaultMaxJitter,
-			MaxWait:   DefaultMaxWait,
-			WaitType:  WaitStrategy(DefaultWaitType),
+			BaseWait:   defBaseWait,
+			MaxJitter:  DefaultMaxJitter,
+			MaxWait:    DefaultMaxWait,
+			Multiplier: DefaultMultiplier,
+			WaitType:   WaitStrategy(DefaultWaitType),
 		}
 		return cfg
 	}
@@ -173,5 +241,23 @@ func WaitConfigWithDefaults(cfg *WaitConfig, defBaseWait time.Duration) *WaitCon
 		cfg.MaxJitter = cfg.MaxWait
 	}
 
+	if cfg.Multiplier < 1 {
+		cfg.Multiplier = DefaultMultiplier
+	}
+
+	return cfg
+}
+
+// attemptTimeoutWithDefaults sets the defaults of the attempt timeout config.
+//
+// Unlike WaitConfigWithDefaults, it keeps zero MaxWait, so the attempt
+// timeout is not limited by DefaultMaxWait.
+func attemptTimeoutWithDefaults(cfg *WaitConfig) *WaitConfig {
+	maxWait := cfg.MaxWait
+	cfg = WaitConfigWithDefaults(cfg, 0)
+	if maxWait <= 0 {
+		cfg.MaxWait = 0
+	}
+
 	return cfg
 }
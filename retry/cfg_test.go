@@ -17,12 +17,14 @@ import (
 
 var (
 	fullRetryCfgFn, partRetryCfgFn func() *Config
+	timeoutRetryCfgFn              func() *Config
 	fullWaitCfgFn, partWaitCfgFn   func() *WaitConfig
 )
 
 func init() {
 	fullRetryCfgFn = GetRetryConfig("retries.full")
 	partRetryCfgFn = GetRetryConfig("retries.part")
+	timeoutRetryCfgFn = GetRetryConfig("retries.timeout")
 	fullWaitCfgFn = GetWaitConfig("wait.full", DefaultBaseWait)
 	partWaitCfgFn = GetWaitConfig("wait.part", DefaultBaseWait)
 }
@@ -89,6 +91,28 @@ func TestNewPartRetryConfig(t *testing.T) {
 	require.True(t, time.Now().UnixNano()-start >= (DefaultAttempts-1)*10*time.Millisecond.Nanoseconds())
 }
 
+func TestNewAttemptTimeoutConfig(t *testing.T) {
+	configPath, err := filepath.Abs("testdata/retry.yml")
+	require.NoError(t, err)
+
+	os.Args = append(os.Args, "--config="+configPath)
+	err = config.InitOnce()
+	require.NoError(t, err)
+
+	cfg := timeoutRetryCfgFn()
+	require.NotNil(t, cfg.AttemptTimeout)
+
+	assert.Equal(t, BackOff, cfg.AttemptTimeout.WaitType)
+	assert.Equal(t, time.Second, cfg.AttemptTimeout.BaseWait)
+	assert.Zero(t, cfg.AttemptTimeout.MaxWait)
+
+	// NOTE: the attempt timeout is not limited by DefaultMaxWait.
+	waiter := NewWaiter(cfg.AttemptTimeout)
+	assert.Equal(t, time.Second, waiter.Get(0))
+	assert.Equal(t, 2*time.Second, waiter.Get(1))
+	assert.Equal(t, 4*time.Second, waiter.Get(2))
+}
+
 func TestNewFullWaitConfig(t *testing.T) {
 	configPath, err := filepath.Abs("testdata/retry.yml")
 	require.NoError(t, err)
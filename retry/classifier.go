@@ -0,0 +1,105 @@
+package retry
+
+import (
+	"errors"
+	"net"
+)
+
+// Classifier decides whether an error can be retried.
+type Classifier interface {
+	// Retryable returns true if the action failed with err can be retried.
+	Retryable(err error) bool
+}
+
+// ClassifierFunc is an adapter to use ordinary functions as Classifier.
+type ClassifierFunc func(err error) bool
+
+// Retryable calls f(err).
+func (f ClassifierFunc) Retryable(err error) bool {
+	return f(err)
+}
+
+var (
+	// NetTimeout classifies network timeouts as retryable.
+	NetTimeout Classifier = ClassifierFunc(func(err error) bool {
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	})
+)
+
+// AnyOf returns Classifier which treats an error as retryable
+// if any of the given classifiers does. Nil classifiers are skipped.
+func AnyOf(classifiers ...Classifier) Classifier {
+	return ClassifierFunc(func(err error) bool {
+		for _, c := range classifiers {
+			if c != nil && c.Retryable(err) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not returns Classifier which inverts the given one.
+//
+// It is useful to exclude some errors, e.g. AllOf(c, Not(MatchErrors(ErrFatal))).
+func Not(c Classifier) Classifier {
+	return ClassifierFunc(func(err error) bool {
+		return !c.Retryable(err)
+	})
+}
+
+// AllOf returns Classifier which treats an error as retryable
+// if all the given classifiers do. Nil classifiers are skipped.
+func AllOf(classifiers ...Classifier) Classifier {
+	return ClassifierFunc(func(err error) bool {
+		for _, c := range classifiers {
+			if c != nil && !c.Retryable(err) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// MatchErrors returns Classifier which treats an error as retryable
+// if it matches any of the targets with errors.Is.
+func MatchErrors(targets ...error) Classifier {
+	return ClassifierFunc(func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MatchCodes returns Classifier which treats an error as retryable
+// if its code is one of the given codes.
+//
+// codeOf extracts the code from the error and returns false if the error has no code.
+func MatchCodes[T comparable](codeOf func(err error) (T, bool), codes ...T) Classifier {
+	set := make(map[T]struct{}, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+
+	return ClassifierFunc(func(err error) bool {
+		code, ok := codeOf(err)
+		if !ok {
+			return false
+		}
+		_, ok = set[code]
+		return ok
+	})
+}
+
+// Classify returns err as is if it is retryable, otherwise err is marked as Unrecoverable.
+func Classify(c Classifier, err error) error {
+	if err == nil || c.Retryable(err) {
+		return err
+	}
+
+	return Unrecoverable(err)
+}
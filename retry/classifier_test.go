@@ -0,0 +1,82 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/avast/retry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type codeError struct {
+	code int
+}
+
+func (e *codeError) Error() string {
+	return fmt.Sprintf("code %d", e.code)
+}
+
+func codeOf(err error) (int, bool) {
+	var codeErr *codeError
+	if errors.As(err, &codeErr) {
+		return codeErr.code, true
+	}
+	return 0, false
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifiers(t *testing.T) {
+	errTarget := errors.New("target")
+	codes := MatchCodes(codeOf, 429, 503)
+
+	assert.True(t, codes.Retryable(&codeError{code: 503}))
+	assert.True(t, codes.Retryable(fmt.Errorf("wrapped: %w", &codeError{code: 429})))
+	assert.False(t, codes.Retryable(&codeError{code: 500}))
+	assert.False(t, codes.Retryable(errTarget))
+
+	matchErrs := MatchErrors(errTarget)
+	assert.True(t, matchErrs.Retryable(fmt.Errorf("wrapped: %w", errTarget)))
+	assert.False(t, matchErrs.Retryable(errors.New("other")))
+
+	assert.True(t, NetTimeout.Retryable(fmt.Errorf("wrapped: %w", timeoutError{})))
+	assert.False(t, NetTimeout.Retryable(errTarget))
+
+	anyOf := AnyOf(nil, codes, matchErrs)
+	assert.True(t, anyOf.Retryable(errTarget))
+	assert.True(t, anyOf.Retryable(&codeError{code: 503}))
+	assert.False(t, anyOf.Retryable(errors.New("other")))
+
+	allOf := AllOf(codes, Not(MatchCodes(codeOf, 429)))
+	assert.True(t, allOf.Retryable(&codeError{code: 503}))
+	assert.False(t, allOf.Retryable(&codeError{code: 429}))
+}
+
+func TestRetrier_Classifier(t *testing.T) {
+	retrier := New(ConfigWithDefaults(&Config{
+		MaxAttempts: 3,
+	}, 0))
+	retrier.SetClassifier(MatchCodes(codeOf, 503))
+
+	var calls int
+	err := retrier.Do(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return &codeError{code: 503}
+		}
+		return &codeError{code: 500}
+	}, func(uint, error) {})
+	require.Error(t, err)
+	assert.Equal(t, 2, calls)
+
+	assert.NoError(t, Classify(NetTimeout, nil))
+	assert.Equal(t, timeoutError{}, Classify(NetTimeout, timeoutError{}))
+	assert.False(t, retry.IsRecoverable(Classify(NetTimeout, errors.New("fatal"))))
+}
@@ -0,0 +1,117 @@
+package retry
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/avast/retry-go"
+)
+
+// decorrelatedGrowth is a growth factor of the DecorrelatedJitter upper bound.
+const decorrelatedGrowth = 3
+
+func getDelayFunc(cfg *WaitConfig) retry.DelayTypeFunc {
+	switch cfg.WaitType {
+	case BackOff:
+		return func(n uint, _ error, _ *retry.Config) time.Duration {
+			return backOff(cfg, n)
+		}
+	case Random:
+		return retry.RandomDelay
+	case Combine:
+		return retry.CombineDelay(func(n uint, _ error, _ *retry.Config) time.Duration {
+			return backOff(cfg, n)
+		}, retry.RandomDelay)
+	case FullJitter:
+		return func(n uint, _ error, _ *retry.Config) time.Duration {
+			return randomBetween(0, backOff(cfg, n))
+		}
+	case DecorrelatedJitter:
+		return func(n uint, _ error, _ *retry.Config) time.Duration {
+			return decorrelatedJitter(cfg, n)
+		}
+	default:
+		return retry.FixedDelay
+	}
+}
+
+// withDelayer limits the delay of delayFn by maxWait and extends it
+// up to the delay required by the error implementing Delayer.
+func withDelayer(delayFn retry.DelayTypeFunc, maxWait time.Duration) retry.DelayTypeFunc {
+	return func(n uint, err error, cfg *retry.Config) time.Duration {
+		delay := delayFn(n, err, cfg)
+		if maxWait > 0 && delay > maxWait {
+			delay = maxWait
+		}
+
+		var d Delayer
+		if errors.As(err, &d) && d.RetryDelay() > delay {
+			delay = d.RetryDelay()
+		}
+
+		return delay
+	}
+}
+
+// backOff returns BaseWait * Multiplier^n limited by MaxWait.
+func backOff(cfg *WaitConfig, n uint) time.Duration {
+	if cfg.BaseWait <= 0 {
+		return 0
+	}
+
+	multiplier := cfg.Multiplier
+	if multiplier < 1 {
+		multiplier = DefaultMultiplier
+	}
+
+	limit := maxWait(cfg)
+	wait := float64(cfg.BaseWait) * math.Pow(multiplier, float64(n))
+	if wait >= float64(limit) {
+		return limit
+	}
+
+	return time.Duration(wait)
+}
+
+// decorrelatedJitter returns the n-th wait of the sequence
+// wait = min(MaxWait, random_between(BaseWait, 3 * previous wait)) starting from BaseWait.
+//
+// The sequence is replayed from the start on each call, so the Waiter stays
+// stateless and can be shared between concurrent calls.
+func decorrelatedJitter(cfg *WaitConfig, n uint) time.Duration {
+	wait := cfg.BaseWait
+	if wait <= 0 {
+		return 0
+	}
+
+	limit := maxWait(cfg)
+	for i := uint(0); i < n; i++ {
+		upper := wait * decorrelatedGrowth
+		if upper < wait || upper > limit {
+			upper = limit
+		}
+		wait = randomBetween(cfg.BaseWait, upper)
+	}
+
+	return wait
+}
+
+// maxWait returns MaxWait or the maximum duration if MaxWait is not set.
+func maxWait(cfg *WaitConfig) time.Duration {
+	if cfg.MaxWait <= 0 {
+		return math.MaxInt64
+	}
+
+	return cfg.MaxWait
+}
+
+// randomBetween returns a random duration in [from, to).
+func randomBetween(from, to time.Duration) time.Duration {
+	if to <= from {
+		return from
+	}
+
+	return from + time.Duration(rand.Int63n(int64(to-from)))
+}
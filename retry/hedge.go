@@ -0,0 +1,249 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/avast/retry-go"
+
+	"github.com/city-mobil/gobuns/config"
+)
+
+const (
+	DefaultHedgeDelay       = 10 * time.Millisecond
+	DefaultHedgeMaxAttempts = 2
+)
+
+// HedgeConfig is a configuration of hedged requests.
+type HedgeConfig struct {
+	// Delay is a duration to wait for the running attempts
+	// before the next attempt is started in parallel.
+	Delay time.Duration
+
+	// MaxAttempts is a maximum amount of attempts including the first one.
+	MaxAttempts int
+
+	// MaxParallel is a maximum amount of attempts running at the same time.
+	//
+	// By default: MaxAttempts.
+	MaxParallel int
+}
+
+func GetHedgeConfig(prefix string, set ...*config.FlagSet) func() *HedgeConfig {
+	fs := config.FlagSetOrDefault(set...)
+
+	prefix = config.SanitizePrefix(prefix)
+	var (
+		delay       = fs.Duration(prefix+"delay", DefaultHedgeDelay, "delay before the next hedged attempt is started")
+		maxAttempts = fs.Int(prefix+"max_attempts", DefaultHedgeMaxAttempts, "max hedged attempts including the first one")
+		maxParallel = fs.Int(prefix+"max_parallel", 0, "max hedged attempts running in parallel, 0 means max_attempts")
+	)
+
+	return func() *HedgeConfig {
+		return HedgeConfigWithDefaults(&HedgeConfig{
+			Delay:       *delay,
+			MaxAttempts: *maxAttempts,
+			MaxParallel: *maxParallel,
+		})
+	}
+}
+
+func NewDefHedgeConfig() *HedgeConfig {
+	return HedgeConfigWithDefaults(nil)
+}
+
+func HedgeConfigWithDefaults(cfg *HedgeConfig) *HedgeConfig {
+	if cfg == nil {
+		return &HedgeConfig{
+			Delay:       DefaultHedgeDelay,
+			MaxAttempts: DefaultHedgeMaxAttempts,
+			MaxParallel: DefaultHedgeMaxAttempts,
+		}
+	}
+
+	if cfg.Delay < 0 {
+		cfg.Delay = DefaultHedgeDelay
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultHedgeMaxAttempts
+	}
+
+	if cfg.MaxParallel <= 0 || cfg.MaxParallel > cfg.MaxAttempts {
+		cfg.MaxParallel = cfg.MaxAttempts
+	}
+
+	return cfg
+}
+
+// HedgeResult describes a single hedged call.
+type HedgeResult struct {
+	// Attempts is an amount of started attempts.
+	Attempts uint
+	// Winner is a zero-based number of the successful attempt, -1 if all the attempts failed.
+	Winner int
+	// Duration is a duration of the call.
+	Duration time.Duration
+}
+
+// HedgeHookFunc is called after each hedged call, e.g. to collect metrics.
+//
+// It is called synchronously, so it should not block.
+type HedgeHookFunc = func(res HedgeResult)
+
+// HedgeOption is an optional Hedger parameter.
+type HedgeOption func(*Hedger)
+
+// WithHedgeHook sets the callback called after each hedged call.
+func WithHedgeHook(fn HedgeHookFunc) HedgeOption {
+	return func(h *Hedger) {
+		h.hook = fn
+	}
+}
+
+// WithHedgeClassifier sets the classifier of retryable errors.
+//
+// An error classified as non-retryable stops the call in the same way as an Unrecoverable one.
+func WithHedgeClassifier(c Classifier) HedgeOption {
+	return func(h *Hedger) {
+		h.classifier = c
+	}
+}
+
+// Hedger executes hedged requests to cut the tail latency.
+//
+// The first attempt starts immediately. If no attempt has succeeded after
+// HedgeConfig.Delay, the next attempt starts in parallel. A failed attempt
+// starts the next one without waiting. The first successful attempt wins,
+// the contexts of the others are cancelled.
+type Hedger struct {
+	cfg        *HedgeConfig
+	hook       HedgeHookFunc
+	classifier Classifier
+}
+
+// NewHedger creates new Hedger.
+func NewHedger(cfg *HedgeConfig, opts ...HedgeOption) *Hedger {
+	h := &Hedger{
+		cfg: cfg,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *Hedger) retryable(err error) bool {
+	if !retry.IsRecoverable(err) {
+		return false
+	}
+
+	return h.classifier == nil || h.classifier.Retryable(err)
+}
+
+// Do executes hedged action.
+//
+// The action must be safe to run concurrently and must respect context cancellation.
+func (h *Hedger) Do(ctx context.Context, action ContextAction) error {
+	_, err := Hedge(ctx, h, func(ctx context.Context, _ uint) (struct{}, error) {
+		return struct{}{}, action(ctx)
+	})
+
+	return err
+}
+
+// Hedge executes hedged action and returns the value of the first successful attempt.
+//
+// If all the attempts failed, the error of type Errors with all the attempt errors
+// is returned. An unrecoverable error stops the call without waiting for other attempts.
+func Hedge[T any](ctx context.Context, h *Hedger, action ValueAction[T]) (T, error) {
+	var zero T
+	if h.cfg.MaxAttempts < 1 {
+		return zero, ErrNoAttempts
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		attempt uint
+		value   T
+		err     error
+	}
+
+	var (
+		started  = time.Now()
+		results  = make(chan result, h.cfg.MaxAttempts)
+		attempts uint
+		running  int
+		errs     Errors
+		timer    *time.Timer
+		next     <-chan time.Time
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	start := func() {
+		attempt := attempts
+		attempts++
+		running++
+		go func() {
+			v, err := action(ctx, attempt)
+			results <- result{attempt: attempt, value: v, err: err}
+		}()
+
+		if timer != nil {
+			timer.Stop()
+			next = nil
+		}
+		if attempts < uint(h.cfg.MaxAttempts) {
+			timer = time.NewTimer(h.cfg.Delay)
+			next = timer.C
+		}
+	}
+	done := func(winner int) {
+		if h.hook != nil {
+			h.hook(HedgeResult{
+				Attempts: attempts,
+				Winner:   winner,
+				Duration: time.Since(started),
+			})
+		}
+	}
+
+	start()
+	for {
+		select {
+		case res := <-results:
+			running--
+			if res.err == nil {
+				done(int(res.attempt))
+				return res.value, nil
+			}
+
+			errs = append(errs, res.err)
+			if !h.retryable(res.err) {
+				done(-1)
+				return zero, errs
+			}
+			if attempts < uint(h.cfg.MaxAttempts) {
+				start()
+			} else if running == 0 {
+				done(-1)
+				return zero, errs
+			}
+		case <-next:
+			next = nil
+			if running < h.cfg.MaxParallel {
+				start()
+			}
+		case <-ctx.Done():
+			done(-1)
+			return zero, append(errs, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedge_SlowFirstAttempt(t *testing.T) {
+	var (
+		cancelled int32
+		res       HedgeResult
+	)
+	h := NewHedger(HedgeConfigWithDefaults(&HedgeConfig{
+		Delay:       10 * time.Millisecond,
+		MaxAttempts: 3,
+	}), WithHedgeHook(func(r HedgeResult) {
+		res = r
+	}))
+
+	v, err := Hedge(context.Background(), h, func(ctx context.Context, attempt uint) (uint, error) {
+		if attempt == 0 {
+			<-ctx.Done()
+			atomic.StoreInt32(&cancelled, 1)
+			return 0, ctx.Err()
+		}
+		return attempt, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), v)
+	assert.Equal(t, uint(2), res.Attempts)
+	assert.Equal(t, 1, res.Winner)
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&cancelled) == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestHedge_FastFirstAttempt(t *testing.T) {
+	var calls int32
+	h := NewHedger(HedgeConfigWithDefaults(&HedgeConfig{
+		Delay:       time.Second,
+		MaxAttempts: 3,
+	}))
+
+	err := h.Do(context.Background(), func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestHedge_FailedAttemptStartsNext(t *testing.T) {
+	h := NewHedger(HedgeConfigWithDefaults(&HedgeConfig{
+		Delay:       time.Hour,
+		MaxAttempts: 2,
+	}))
+
+	start := time.Now()
+	v, err := Hedge(context.Background(), h, func(ctx context.Context, attempt uint) (string, error) {
+		if attempt == 0 {
+			return "", errors.New("failed")
+		}
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", v)
+	assert.WithinDuration(t, start, time.Now(), time.Second)
+}
+
+func TestHedge_AllFailed(t *testing.T) {
+	errFailed := errors.New("failed")
+	var res HedgeResult
+	h := NewHedger(HedgeConfigWithDefaults(&HedgeConfig{
+		Delay:       time.Millisecond,
+		MaxAttempts: 3,
+	}), WithHedgeHook(func(r HedgeResult) {
+		res = r
+	}))
+
+	err := h.Do(context.Background(), func(ctx context.Context) error {
+		return errFailed
+	})
+	require.Error(t, err)
+
+	var errs Errors
+	require.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 3)
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, uint(3), res.Attempts)
+	assert.Equal(t, -1, res.Winner)
+}
+
+func TestHedge_Unrecoverable(t *testing.T) {
+	var calls int32
+	h := NewHedger(HedgeConfigWithDefaults(&HedgeConfig{
+		Delay:       time.Hour,
+		MaxAttempts: 3,
+	}))
+
+	err := h.Do(context.Background(), func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return Unrecoverable(errors.New("fatal"))
+	})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestHedge_MaxParallel(t *testing.T) {
+	var (
+		running, maxRunning int32
+	)
+	h := NewHedger(HedgeConfigWithDefaults(&HedgeConfig{
+		Delay:       time.Millisecond,
+		MaxAttempts: 4,
+		MaxParallel: 2,
+	}))
+
+	err := h.Do(context.Background(), func(ctx context.Context) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		return errors.New("failed")
+	})
+	require.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+}
+
+func TestHedge_ContextCanceled(t *testing.T) {
+	h := NewHedger(NewDefHedgeConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := h.Do(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -3,6 +3,7 @@ package retry
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/avast/retry-go"
 )
@@ -14,9 +15,22 @@ var (
 // Action is a user function executed by retry policy.
 type Action = func() error
 
+// ContextAction is a user function executed by retry policy
+// with the context of the current attempt.
+type ContextAction = func(ctx context.Context) error
+
 // OnRetryFunc is a function executed before every retry.
 type OnRetryFunc = func(n uint, err error)
 
+// Delayer is implemented by errors which require to wait at least
+// the given duration before the next retry, e.g. from the HTTP Retry-After header.
+//
+// The delay is used instead of the wait strategy one if it is longer,
+// MaxWait does not limit it.
+type Delayer interface {
+	RetryDelay() time.Duration
+}
+
 type Retrier struct {
 	cfg  *Config
 	opts []retry.Option
@@ -24,37 +38,134 @@ type Retrier struct {
 	// delayFn is called to return the next delay to wait after
 	// the retriable function fails on `err` after `n` attempts.
 	delayFn retry.DelayTypeFunc
+
+	// attemptTimeout calculates the timeout of the given attempt.
+	attemptTimeout *Waiter
+	budget         *Budget
+	classifier     Classifier
 }
 
 func New(cfg *Config) *Retrier {
-	delayFn := getDelayFunc(cfg.WaitType)
+	delayFn := withDelayer(getDelayFunc(&cfg.WaitConfig), cfg.MaxWait)
 
 	opts := []retry.Option{
-		retry.MaxDelay(cfg.MaxWait),
 		retry.Attempts(uint(cfg.MaxAttempts)),
 		retry.Delay(cfg.BaseWait),
 		retry.MaxJitter(cfg.MaxJitter),
 		retry.DelayType(delayFn),
 	}
 
-	return &Retrier{
+	r := &Retrier{
 		cfg:     cfg,
 		opts:    opts,
 		delayFn: delayFn,
 	}
+	if cfg.AttemptTimeout != nil {
+		r.attemptTimeout = NewWaiter(cfg.AttemptTimeout)
+	}
+	if cfg.Budget != nil && cfg.Budget.Percent > 0 {
+		r.budget = NewBudget(cfg.Budget)
+	}
+
+	return r
+}
+
+// SetBudget sets the retry budget shared with other Retriers.
+//
+// It must be called before the Retrier is used.
+func (p *Retrier) SetBudget(b *Budget) {
+	p.budget = b
+}
+
+// SetClassifier sets the classifier of retryable errors.
+//
+// Errors classified as non-retryable stop retries in the same way as Unrecoverable ones.
+// It must be called before the Retrier is used.
+func (p *Retrier) SetClassifier(c Classifier) {
+	p.classifier = c
 }
 
 // Do executes action and retries it in case of recoverable errors.
+//
+// The action has no access to the attempt context, use DoContext
+// to apply per-attempt timeouts.
 func (p *Retrier) Do(ctx context.Context, action Action, onRetry OnRetryFunc) error {
+	return p.DoContext(ctx, func(context.Context) error {
+		return action()
+	}, onRetry)
+}
+
+// DoContext executes action and retries it in case of recoverable errors.
+//
+// Each attempt gets its own context with the deadline calculated from
+// Config.AttemptTimeout. If the retry budget is exhausted, the error
+// of type *BudgetExhaustedError is returned.
+func (p *Retrier) DoContext(ctx context.Context, action ContextAction, onRetry OnRetryFunc) error {
 	if p.cfg.MaxAttempts < 1 {
 		return ErrNoAttempts
 	}
 
-	opts := make([]retry.Option, 0, len(p.opts)+2)
-	opts = append(opts, retry.Context(ctx), retry.OnRetry(onRetry))
+	if p.budget != nil {
+		p.budget.onCall()
+	}
+
+	var (
+		attempt   uint
+		exhausted bool
+		lastErr   error
+	)
+	retryableFn := func() error {
+		attemptCtx, cancel := p.attemptContext(ctx, attempt)
+		defer cancel()
+
+		attempt++
+		lastErr = action(attemptCtx)
+		return lastErr
+	}
+	retryIf := func(err error) bool {
+		if !retry.IsRecoverable(err) {
+			return false
+		}
+		if p.classifier != nil && !p.classifier.Retryable(err) {
+			return false
+		}
+		// NOTE: retry-go asks about the last attempt too, the token must not be spent on it.
+		if p.budget == nil || attempt >= uint(p.cfg.MaxAttempts) {
+			return true
+		}
+		if !p.budget.tryRetry() {
+			exhausted = true
+			return false
+		}
+		return true
+	}
+
+	opts := make([]retry.Option, 0, len(p.opts)+3)
+	opts = append(opts, retry.Context(ctx), retry.OnRetry(onRetry), retry.RetryIf(retryIf))
 	opts = append(opts, p.opts...)
 
-	return retry.Do(action, opts...)
+	err := retry.Do(retryableFn, opts...)
+	if err != nil && exhausted {
+		return &BudgetExhaustedError{
+			Attempts: attempt,
+			Err:      lastErr,
+		}
+	}
+
+	return err
+}
+
+func (p *Retrier) attemptContext(ctx context.Context, attempt uint) (context.Context, context.CancelFunc) {
+	if p.attemptTimeout == nil {
+		return ctx, func() {}
+	}
+
+	timeout := p.attemptTimeout.Get(attempt)
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
 }
 
 func Unrecoverable(err error) error {
@@ -3,6 +3,7 @@ package retry
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -62,3 +63,88 @@ func TestRetrier_NoAttempts(t *testing.T) {
 	err := retrier.Do(context.Background(), action, onRetry)
 	assert.ErrorIs(t, err, ErrNoAttempts)
 }
+
+func TestRetrier_AttemptTimeout(t *testing.T) {
+	cfg := ConfigWithDefaults(&Config{
+		MaxAttempts: 3,
+		AttemptTimeout: &WaitConfig{
+			BaseWait: 10 * time.Millisecond,
+			MaxWait:  time.Second,
+			WaitType: BackOff,
+		},
+	}, 0)
+	retrier := New(cfg)
+
+	var deadlines []time.Duration
+	action := func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		deadlines = append(deadlines, time.Until(deadline))
+
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	err := retrier.DoContext(context.Background(), action, func(n uint, err error) {})
+	assert.Error(t, err)
+	assert.Len(t, deadlines, 3)
+	assert.True(t, deadlines[0] <= 10*time.Millisecond)
+	assert.True(t, deadlines[2] > 20*time.Millisecond)
+}
+
+func TestRetrier_Budget(t *testing.T) {
+	cfg := ConfigWithDefaults(&Config{
+		MaxAttempts: 3,
+		Budget: &BudgetConfig{
+			Percent:   10,
+			MaxTokens: 2,
+		},
+	}, 0)
+	retrier := New(cfg)
+
+	calls := 0
+	action := func() error {
+		calls++
+		return errors.New("temporary error")
+	}
+
+	err := retrier.Do(context.Background(), action, func(n uint, err error) {})
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrBudgetExhausted))
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = retrier.Do(context.Background(), action, func(n uint, err error) {})
+	assert.True(t, errors.Is(err, ErrBudgetExhausted))
+	assert.Equal(t, 1, calls)
+
+	var budgetErr *BudgetExhaustedError
+	assert.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, uint(1), budgetErr.Attempts)
+	assert.EqualError(t, budgetErr.Err, "temporary error")
+}
+
+type delayError time.Duration
+
+func (e delayError) Error() string {
+	return "retry later"
+}
+
+func (e delayError) RetryDelay() time.Duration {
+	return time.Duration(e)
+}
+
+func TestRetrier_Delayer(t *testing.T) {
+	cfg := NewDefRetryConfig()
+	cfg.MaxAttempts = 2
+	retrier := New(cfg)
+
+	action := func() error {
+		return fmt.Errorf("wrapped: %w", delayError(50*time.Millisecond))
+	}
+
+	start := time.Now()
+	err := retrier.Do(context.Background(), action, func(n uint, err error) {})
+	assert.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
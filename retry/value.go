@@ -0,0 +1,83 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/avast/retry-go"
+)
+
+// Errors aggregates errors of all the made attempts.
+//
+// It supports errors.Is and errors.As for each attempt error.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for i, err := range e {
+		msgs = append(msgs, fmt.Sprintf("#%d: %s", i+1, err))
+	}
+
+	return fmt.Sprintf("all %d attempts failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// ValueAction is a user function returning a value executed by retry policy.
+//
+// attempt is a zero-based number of the current attempt, ctx is the context of the attempt.
+type ValueAction[T any] func(ctx context.Context, attempt uint) (T, error)
+
+// DoValue executes action with the retry policy of the given Retrier
+// and returns the value of the first successful attempt.
+//
+// If all the attempts failed, the error of type Errors with all the attempt errors
+// is returned. If the retry budget is exhausted, *BudgetExhaustedError wraps Errors.
+func DoValue[T any](ctx context.Context, r *Retrier, action ValueAction[T]) (T, error) {
+	var (
+		res     T
+		attempt uint
+		errs    Errors
+	)
+
+	err := r.DoContext(ctx, func(ctx context.Context) error {
+		v, err := action(ctx, attempt)
+		attempt++
+		if err != nil {
+			return err
+		}
+
+		res = v
+		return nil
+	}, func(_ uint, err error) {
+		errs = append(errs, err)
+	})
+	if err == nil {
+		return res, nil
+	}
+
+	var zero T
+	switch v := err.(type) {
+	case retry.Error:
+		// NOTE: retry-go keeps the attempt errors unwrapped from Unrecoverable.
+		errs = errs[:0]
+		for _, e := range v {
+			if e != nil {
+				errs = append(errs, e)
+			}
+		}
+		return zero, errs
+	case *BudgetExhaustedError:
+		v.Err = append(errs, v.Err)
+		return zero, v
+	default:
+		// NOTE: the context is done or no attempts are allowed.
+		if len(errs) == 0 {
+			return zero, err
+		}
+		return zero, append(errs, err)
+	}
+}
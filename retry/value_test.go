@@ -0,0 +1,66 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoValue_Success(t *testing.T) {
+	retrier := New(ConfigWithDefaults(&Config{MaxAttempts: 3}, 0))
+
+	var attempts []uint
+	res, err := DoValue(context.Background(), retrier, func(ctx context.Context, attempt uint) (string, error) {
+		attempts = append(attempts, attempt)
+		if attempt < 2 {
+			return "", errors.New("temporary error")
+		}
+		return "value", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "value", res)
+	assert.Equal(t, []uint{0, 1, 2}, attempts)
+}
+
+func TestDoValue_AllAttemptsFailed(t *testing.T) {
+	retrier := New(ConfigWithDefaults(&Config{MaxAttempts: 3}, 0))
+
+	errFatal := errors.New("fatal error")
+	res, err := DoValue(context.Background(), retrier, func(ctx context.Context, attempt uint) (int, error) {
+		if attempt == 1 {
+			return 0, Unrecoverable(errFatal)
+		}
+		return 42, errors.New("temporary error")
+	})
+	require.Error(t, err)
+	assert.Equal(t, 0, res)
+	assert.True(t, errors.Is(err, errFatal))
+
+	var errs Errors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], "temporary error")
+	assert.Equal(t, errFatal, errs[1])
+}
+
+func TestDoValue_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	retrier := New(ConfigWithDefaults(&Config{
+		WaitConfig:  WaitConfig{BaseWait: time.Second},
+		MaxAttempts: 3,
+	}, 0))
+
+	_, err := DoValue(ctx, retrier, func(ctx context.Context, attempt uint) (int, error) {
+		cancel()
+		return 0, errors.New("temporary error")
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	var errs Errors
+	require.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 2)
+}
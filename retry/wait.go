@@ -13,7 +13,7 @@ type Waiter struct {
 }
 
 func NewWaiter(cfg *WaitConfig) *Waiter {
-	waitFn := getDelayFunc(cfg.WaitType)
+	waitFn := getDelayFunc(cfg)
 
 	opts := []retry.Option{
 		retry.MaxDelay(cfg.MaxWait),
@@ -35,9 +35,11 @@ func NewWaiter(cfg *WaitConfig) *Waiter {
 }
 
 // Get returns the wait duration for given iteration.
+//
+// The duration is limited by MaxWait if it is positive.
 func (w *Waiter) Get(iter uint) time.Duration {
 	wait := w.waitFn(iter, nil, w.libCfg)
-	if wait > w.userCfg.MaxWait {
+	if w.userCfg.MaxWait > 0 && wait > w.userCfg.MaxWait {
 		wait = w.userCfg.MaxWait
 	}
 	return wait
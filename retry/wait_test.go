@@ -38,3 +38,50 @@ func TestNewDefaultWaiter(t *testing.T) {
 	assert.Equal(t, DefaultBaseWait, waiter.Get(1))
 	assert.Equal(t, DefaultBaseWait, waiter.Get(10))
 }
+
+func TestNewBackOffWaiterWithMultiplier(t *testing.T) {
+	waiter := NewWaiter(WaitConfigWithDefaults(&WaitConfig{
+		BaseWait:   DefaultBaseWait,
+		MaxWait:    1 * time.Second,
+		Multiplier: 3,
+		WaitType:   BackOff,
+	}, time.Second))
+
+	assert.Equal(t, DefaultBaseWait, waiter.Get(0))
+	assert.Equal(t, 3*DefaultBaseWait, waiter.Get(1))
+	assert.Equal(t, 9*DefaultBaseWait, waiter.Get(2))
+	assert.Equal(t, 1*time.Second, waiter.Get(10))
+}
+
+func TestNewFullJitterWaiter(t *testing.T) {
+	waiter := NewWaiter(WaitConfigWithDefaults(&WaitConfig{
+		BaseWait: DefaultBaseWait,
+		MaxWait:  1 * time.Second,
+		WaitType: FullJitter,
+	}, time.Second))
+
+	for i := 0; i < 100; i++ {
+		assert.Less(t, waiter.Get(0), DefaultBaseWait)
+		assert.Less(t, waiter.Get(3), 8*DefaultBaseWait)
+		assert.Less(t, waiter.Get(10), 1*time.Second)
+	}
+}
+
+func TestNewDecorrelatedJitterWaiter(t *testing.T) {
+	waiter := NewWaiter(WaitConfigWithDefaults(&WaitConfig{
+		BaseWait: DefaultBaseWait,
+		MaxWait:  1 * time.Second,
+		WaitType: DecorrelatedJitter,
+	}, time.Second))
+
+	assert.Equal(t, DefaultBaseWait, waiter.Get(0))
+	for i := 0; i < 100; i++ {
+		wait := waiter.Get(1)
+		assert.GreaterOrEqual(t, wait, DefaultBaseWait)
+		assert.Less(t, wait, 3*DefaultBaseWait)
+
+		wait = waiter.Get(10)
+		assert.GreaterOrEqual(t, wait, DefaultBaseWait)
+		assert.LessOrEqual(t, wait, 1*time.Second)
+	}
+}
@@ -57,6 +57,60 @@ var tntRetryableErrors = []uint{
 	tarantool.ErrTimeout,
 }
 
+// DefaultClassifier classifies tarantool connection errors and timeouts as retryable.
+var DefaultClassifier retry.Classifier = retry.ClassifierFunc(func(err error) bool {
+	code, ok := ErrorCode(err)
+	if !ok {
+		return false
+	}
+
+	for _, rc := range tntRetryableErrors {
+		if rc == code {
+			return true
+		}
+	}
+	return false
+})
+
+// NewClassifier returns DefaultClassifier extended with the given tarantool error codes.
+func NewClassifier(codes ...uint) retry.Classifier {
+	if len(codes) == 0 {
+		return DefaultClassifier
+	}
+
+	return retry.AnyOf(DefaultClassifier, retry.MatchCodes(ErrorCode, codes...))
+}
+
+// resultError is an error of the query result with its code passed to the classifier.
+type resultError struct {
+	code uint
+	err  error
+}
+
+func (e *resultError) Error() string {
+	return e.err.Error()
+}
+
+func (e *resultError) Unwrap() error {
+	return e.err
+}
+
+// ErrorCode returns the tarantool error code of the query error.
+// It returns false if the error has no code.
+func ErrorCode(err error) (uint, bool) {
+	var resErr *resultError
+	if errors.As(err, &resErr) {
+		return resErr.code, true
+	}
+
+	var queryErr *tarantool.QueryError
+	if errors.As(err, &queryErr) {
+		return queryErr.Code, true
+	}
+
+	return 0, false
+}
+
 var (
 	// ExecOnFailedRetry calls every time when a retry was failed.
 	ExecOnFailedRetry = func(n uint, addr, reply string) {
@@ -100,6 +154,17 @@ type ShardConfig struct {
 
 	// QueryTimeoutConfig is a configuration for query timeout policy.
 	QueryTimeoutConfig *retry.WaitConfig
+
+	// RetryableCodes is a list of Tarantool error codes which are retryable
+	// in addition to the ones of DefaultClassifier.
+	//
+	// If Classifier is set, RetryableCodes are ignored.
+	RetryableCodes []uint
+
+	// Classifier decides whether a query error can be retried.
+	//
+	// By default: DefaultClassifier extended with RetryableCodes.
+	Classifier retry.Classifier
 }
 
 // Shard describes a single shard in tarantool cluster.
@@ -148,6 +213,7 @@ type shard struct {
 	onceCloser      sync.Once
 	waiter          *retry.Waiter
 	retrier         *retry.Retrier
+	classifier      retry.Classifier
 	stop            chan struct{}
 }
 
@@ -167,6 +233,7 @@ func NewShard(conf *ShardConfig) (Shard, error) {
 		slaveConnectors: slaveConnectors,
 		waiter:          retry.NewWaiter(conf.QueryTimeoutConfig),
 		retrier:         retry.New(conf.RetryConfig),
+		classifier:      conf.Classifier,
 		stop:            make(chan struct{}),
 	}, nil
 }
@@ -294,13 +361,16 @@ func (s *shard) CallReplicaTolerant(ctx context.Context, query tarantool.Query)
 	return s.callTolerant(ctx, query, s.getRandomSlaveConnector)
 }
 
-func (s *shard) isRetryable(code uint) bool {
-	for _, rc := range tntRetryableErrors {
-		if rc == code {
-			return true
-		}
+func (s *shard) isRetryable(result *tarantool.Result) bool {
+	err := &resultError{
+		code: result.ErrorCode,
+		err:  result.Error,
 	}
-	return false
+	if s.classifier == nil {
+		return DefaultClassifier.Retryable(err)
+	}
+
+	return s.classifier.Retryable(err)
 }
 
 func (s *shard) callTolerant(ctx context.Context, query tarantool.Query, connectorFunc func() (pool.ConnectorPool, error)) (*tarantool.Result, error) {
@@ -349,7 +419,7 @@ func (s *shard) callTolerant(ctx context.Context, query tarantool.Query, connect
 
 		result = s.exec(ctx, conn, query, iter)
 		if result.Error != nil {
-			if s.isRetryable(result.ErrorCode) {
+			if s.isRetryable(result) {
 				if ExecOnFailedRetry != nil {
 					ExecOnFailedRetry(iter, connector.RemoteAddr(), result.String())
 				}
@@ -535,6 +605,11 @@ func shardConfigWithDefaults(conf *ShardConfig) *ShardConfig { // nolint:interfa
 	}
 
 	conf.RetryConfig = retry.ConfigWithDefaults(conf.RetryConfig, 0)
+
+	if conf.Classifier == nil {
+		conf.Classifier = NewClassifier(conf.RetryableCodes...)
+	}
+
 	conf.QueryTimeoutConfig = retry.WaitConfigWithDefaults(conf.QueryTimeoutConfig, DefaultQueryTimeout)
 
 	return conf
@@ -2,7 +2,6 @@ package tntclustercfg
 
 import (
 	"encoding/json"
-	"fmt"
 	"sort"
 	"strings"
 	"time"
@@ -34,26 +33,28 @@ type userShardConfig struct {
 	user              *string
 	password          *string
 	retryCfgFn        func() *retry.Config
+	retryableCodes    *[]uint
 	poolSize          *int
 	maxPoolPacketSize *int
 	name              *string
 }
 
-func defineUserShardConfig(prefix string) *userShardConfig {
+func defineUserShardConfig(prefix string, fs *config.FlagSet) *userShardConfig {
 	prefix = config.SanitizePrefix(prefix)
 
 	var (
-		name              = config.String(prefix+"name", "", "shard name")
-		addr              = config.String(prefix+"addr", DefaultAddrs, "shard master address")
-		slaves            = config.String(prefix+"slaves", DefaultAddrs, "comma separated shard slaves addresses")
-		queryTimeoutFn    = retry.GetWaitConfig(prefix+"query_timeout", DefaultQueryTimeout)
-		connectTimeout    = config.Duration(prefix+"connect_timeout", DefaultConnectTimeout, "shard connect timeout")
-		space             = config.String(prefix+"default_space", DefaultSpace, "default space to connect")
-		user              = config.String(prefix+"user", DefaultUser, "username to connect")
-		password          = config.String(prefix+"password", DefaultPassword, "user password to connect")
-		poolSize          = config.Int(prefix+"pool_size", DefaultPoolSize, "connection pool size")
-		maxPoolPacketSize = config.Int(prefix+"max_pool_packet_size", DefaultMaxPoolPacketSize, "max pool packet size in bytes")
-		retryCfgFn        = retry.GetRetryConfig(prefix + "retries")
+		name              = fs.String(prefix+"name", "", "shard name")
+		addr              = fs.String(prefix+"addr", DefaultAddrs, "shard master address")
+		slaves            = fs.String(prefix+"slaves", DefaultAddrs, "comma separated shard slaves addresses")
+		queryTimeoutFn    = retry.GetWaitConfig(prefix+"query_timeout", DefaultQueryTimeout, fs)
+		connectTimeout    = fs.Duration(prefix+"connect_timeout", DefaultConnectTimeout, "shard connect timeout")
+		space             = fs.String(prefix+"default_space", DefaultSpace, "default space to connect")
+		user              = fs.String(prefix+"user", DefaultUser, "username to connect")
+		password          = fs.Secret(prefix+"password", DefaultPassword, "user password to connect")
+		poolSize          = fs.Int(prefix+"pool_size", DefaultPoolSize, "connection pool size")
+		maxPoolPacketSize = fs.Int(prefix+"max_pool_packet_size", DefaultMaxPoolPacketSize, "max pool packet size in bytes")
+		retryCfgFn        = retry.GetRetryConfig(prefix+"retries", fs)
+		retryableCodes    = fs.UintSlice(prefix+"retries.retryable_codes", nil, "Tarantool error codes retryable in addition to the default ones")
 	)
 
 	return &userShardConfig{
@@ -66,6 +67,7 @@ func defineUserShardConfig(prefix string) *userShardConfig {
 		password:          password,
 		poolSize:          poolSize,
 		retryCfgFn:        retryCfgFn,
+		retryableCodes:    retryableCodes,
 		maxPoolPacketSize: maxPoolPacketSize,
 		name:              name,
 	}
@@ -90,6 +92,7 @@ func newShardConfig(userCfg *userShardConfig) *tntcluster.ShardConfig {
 		SlaveAddrs:         slaves,
 		QueryTimeoutConfig: userCfg.queryTimeout(),
 		RetryConfig:        userCfg.retryCfgFn(),
+		RetryableCodes:     *userCfg.retryableCodes,
 		PoolSize:           *userCfg.poolSize,
 		Name:               *userCfg.name,
 	}
@@ -99,32 +102,37 @@ func newShardConfig(userCfg *userShardConfig) *tntcluster.ShardConfig {
 	return cfg
 }
 
-func NewShardConfig(prefix string) func() *tntcluster.ShardConfig {
-	userCfg := defineUserShardConfig(prefix)
+func NewShardConfig(prefix string, set ...*config.FlagSet) func() *tntcluster.ShardConfig {
+	fs := config.FlagSetOrDefault(set...)
+
+	userCfg := defineUserShardConfig(prefix, fs)
 
 	return func() *tntcluster.ShardConfig {
 		return newShardConfig(userCfg)
 	}
 }
 
-func NewClusterConfig(prefix string) func() (*tntcluster.ClusterConfig, error) {
+func NewClusterConfig(prefix string, set ...*config.FlagSet) func() (*tntcluster.ClusterConfig, error) {
+	fs := config.FlagSetOrDefault(set...)
+
 	prefix = config.SanitizePrefix(prefix) + "tntcluster"
+	shardCfgs := config.Section(prefix, NewShardConfig, fs)
 
 	return func() (*tntcluster.ClusterConfig, error) {
-		defined := config.SubConfigSuffixes(prefix)
-		sort.Strings(defined)
-
-		userShardConfigs := make([]*userShardConfig, 0, len(defined))
-		for _, key := range defined {
-			shardPrefix := fmt.Sprintf("%s.%s", prefix, key)
-			userShardConfigs = append(userShardConfigs, defineUserShardConfig(shardPrefix))
+		defined, err := shardCfgs.Load()
+		if err != nil {
+			return nil, err
 		}
 
-		config.ReInit(prefix)
+		keys := make([]string, 0, len(defined))
+		for key := range defined {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
 
-		shards := make([]*tntcluster.ShardConfig, 0, len(userShardConfigs))
-		for _, cfg := range userShardConfigs {
-			shards = append(shards, newShardConfig(cfg))
+		shards := make([]*tntcluster.ShardConfig, 0, len(keys))
+		for _, key := range keys {
+			shards = append(shards, defined[key])
 		}
 
 		return &tntcluster.ClusterConfig{
@@ -136,20 +144,23 @@ func NewClusterConfig(prefix string) func() (*tntcluster.ClusterConfig, error) {
 // OldClusterConfig is a callback for registering cluster config.
 //
 // It is a deprecated stuff for backporting for legacy stuff users.
-func OldClusterConfig(prefix string) func() (*tntcluster.ClusterConfig, error) {
+func OldClusterConfig(prefix string, set ...*config.FlagSet) func() (*tntcluster.ClusterConfig, error) {
+	fs := config.FlagSetOrDefault(set...)
+
 	prefix = config.SanitizePrefix(prefix)
 
 	var (
-		addrs             = config.String(prefix+"tntcluster.addr", DefaultAddrs, "tnt cluster addrs")
-		slaves            = config.String(prefix+"tntcluster.slave_addrs", "", "tnt cluster slave addrs")
-		queryTimeoutFn    = retry.GetWaitConfig(prefix+"tntcluster.query_timeout", DefaultQueryTimeout)
-		connectTimeout    = config.Duration(prefix+"tntcluster.connect_timeout", DefaultConnectTimeout, "tnt cluster connect timeout")
-		space             = config.String(prefix+"tntcluster.default_space", DefaultSpace, "tnt cluster default space")
-		user              = config.String(prefix+"tntcluster.user", DefaultUser, "tnt cluster user")
-		password          = config.String(prefix+"tntcluster.password", DefaultPassword, "tnt cluster user password")
-		maxPoolPacketSize = config.Int(prefix+"tntcluster.max_pool_packet_size", DefaultMaxPoolPacketSize, "Tnt cluster max pool packet size in bytes")
-		poolSize          = config.Int(prefix+"tntcluster.pool_size", 42, "Tnt cluster connection pool size")
-		retryCfgFn        = retry.GetRetryConfig(prefix + "tntcluster.retries")
+		addrs             = fs.String(prefix+"tntcluster.addr", DefaultAddrs, "tnt cluster addrs")
+		slaves            = fs.String(prefix+"tntcluster.slave_addrs", "", "tnt cluster slave addrs")
+		queryTimeoutFn    = retry.GetWaitConfig(prefix+"tntcluster.query_timeout", DefaultQueryTimeout, fs)
+		connectTimeout    = fs.Duration(prefix+"tntcluster.connect_timeout", DefaultConnectTimeout, "tnt cluster connect timeout")
+		space             = fs.String(prefix+"tntcluster.default_space", DefaultSpace, "tnt cluster default space")
+		user              = fs.String(prefix+"tntcluster.user", DefaultUser, "tnt cluster user")
+		password          = fs.Secret(prefix+"tntcluster.password", DefaultPassword, "tnt cluster user password")
+		maxPoolPacketSize = fs.Int(prefix+"tntcluster.max_pool_packet_size", DefaultMaxPoolPacketSize, "Tnt cluster max pool packet size in bytes")
+		poolSize          = fs.Int(prefix+"tntcluster.pool_size", 42, "Tnt cluster connection pool size")
+		retryCfgFn        = retry.GetRetryConfig(prefix+"tntcluster.retries", fs)
+		retryableCodes    = fs.UintSlice(prefix+"tntcluster.retries.retryable_codes", nil, "Tarantool error codes retryable in addition to the default ones")
 	)
 
 	return func() (*tntcluster.ClusterConfig, error) {
@@ -166,6 +177,7 @@ func OldClusterConfig(prefix string) func() (*tntcluster.ClusterConfig, error) {
 			cfg := &tntcluster.ShardConfig{
 				MasterAddr:         v,
 				RetryConfig:        retryCfgFn(),
+				RetryableCodes:     *retryableCodes,
 				QueryTimeoutConfig: queryTimeoutFn(),
 				Opts: tarantool.Options{
 					ConnectTimeout:    *connectTimeout,
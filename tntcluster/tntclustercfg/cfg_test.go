@@ -43,6 +43,7 @@ func TestNewClusterConfig(t *testing.T) {
 	retryCfg := shard.RetryConfig
 	assert.Equal(t, 3, retryCfg.MaxAttempts)
 	assert.Equal(t, 10*time.Millisecond, retryCfg.BaseWait)
+	assert.Equal(t, []uint{32}, shard.RetryableCodes)
 
 	queryTimeoutCfg := shard.QueryTimeoutConfig
 	assert.Equal(t, 1*time.Minute, queryTimeoutCfg.BaseWait)
@@ -59,6 +60,7 @@ func TestNewClusterConfig(t *testing.T) {
 	assert.Equal(t, 5, retryCfg.MaxAttempts)
 	assert.Equal(t, retry.Random, retryCfg.WaitType)
 	assert.Equal(t, 200*time.Millisecond, retryCfg.MaxJitter)
+	assert.Empty(t, shard.RetryableCodes)
 
 	queryTimeoutCfg = shard.QueryTimeoutConfig
 	assert.Equal(t, retry.Random, queryTimeoutCfg.WaitType)
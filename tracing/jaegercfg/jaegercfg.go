@@ -12,13 +12,15 @@ import (
 const defaultSamplingProbability = 0.001
 
 // JaegerConfig is a callback for registering Jaeger  config.
-func JaegerConfig(prefix string) func() *jaegerconfig.Configuration {
+func JaegerConfig(prefix string, set ...*config.FlagSet) func() *jaegerconfig.Configuration {
+	fs := config.FlagSetOrDefault(set...)
+
 	prefix = config.SanitizePrefix(prefix)
 	var (
-		serviceName = config.String(prefix+"jaeger.service_name", "unknown", "the service name")
-		disabled    = config.Bool(prefix+"jaeger.disabled", false, " tracer is disabled or not")
-		agent       = config.String(prefix+"jaeger.agent", "localhost:6831", "agent addr (UDP)")
-		samplingURL = config.String(prefix+"jaeger.sampling_url", "http://localhost:5778/sampling", "server URL (HTTP)")
+		serviceName = fs.String(prefix+"jaeger.service_name", "unknown", "the service name")
+		disabled    = fs.Bool(prefix+"jaeger.disabled", false, " tracer is disabled or not")
+		agent       = fs.String(prefix+"jaeger.agent", "localhost:6831", "agent addr (UDP)")
+		samplingURL = fs.String(prefix+"jaeger.sampling_url", "http://localhost:5778/sampling", "server URL (HTTP)")
 	)
 
 	return func() *jaegerconfig.Configuration {